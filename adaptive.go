@@ -0,0 +1,177 @@
+package logger
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// AdaptiveSamplingConfig lowers the share of low level entries kept while
+// the outputs fall behind, and raises it back once they catch up. Every
+// interval under pressure halves the rate, down to MinRate, every interval
+// without doubles it, up to all entries.
+type AdaptiveSamplingConfig struct {
+	// QueueHigh is the fill ratio of the async queue, 0 to 1, above which
+	// the outputs are under pressure, default 0.5, ignored without Async
+	QueueHigh float64
+	// LatencyHigh is the average write time of an entry above which the
+	// outputs are under pressure, default 10ms
+	LatencyHigh time.Duration
+	// MinRate is the lowest share of entries kept, default 0.01
+	MinRate float64
+	// MaxLevel is the highest level sampled, info by default, the levels
+	// above are always kept
+	MaxLevel *zapcore.Level
+	// Interval between two adjustments, default 1s
+	Interval time.Duration
+}
+
+func (c AdaptiveSamplingConfig) withDefaults() AdaptiveSamplingConfig {
+	if c.QueueHigh <= 0 {
+		c.QueueHigh = 0.5
+	}
+	if c.LatencyHigh <= 0 {
+		c.LatencyHigh = 10 * time.Millisecond
+	}
+	if c.MinRate <= 0 {
+		c.MinRate = 0.01
+	}
+	if c.MaxLevel == nil {
+		level := zapcore.InfoLevel
+		c.MaxLevel = &level
+	}
+	if c.Interval <= 0 {
+		c.Interval = time.Second
+	}
+	return c
+}
+
+// writeLatency sums the time spent writing entries over an interval
+type writeLatency struct {
+	total atomic.Int64
+	count atomic.Int64
+}
+
+// take returns the average write time since the last call
+func (l *writeLatency) take() time.Duration {
+	count := l.count.Swap(0)
+	total := l.total.Swap(0)
+	if count == 0 {
+		return 0
+	}
+	return time.Duration(total / count)
+}
+
+// latencyCore times the writes of the wrapped core
+type latencyCore struct {
+	zapcore.Core
+	latency *writeLatency
+}
+
+func (c *latencyCore) With(fields []zapcore.Field) zapcore.Core {
+	return &latencyCore{Core: c.Core.With(fields), latency: c.latency}
+}
+
+func (c *latencyCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *latencyCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	start := time.Now()
+	writeEntry(c.Core, ent, fields)
+	c.latency.total.Add(int64(time.Since(start)))
+	c.latency.count.Add(1)
+	return nil
+}
+
+type adaptiveSampler struct {
+	config  AdaptiveSamplingConfig
+	latency *writeLatency
+	// queue returns the fill ratio of the async queue, nil without one
+	queue func() float64
+	// every keeps one entry out of every, 1 keeps them all
+	every atomic.Int64
+	seen  atomic.Uint64
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+func newAdaptiveSampler(config AdaptiveSamplingConfig, latency *writeLatency, queue func() float64) *adaptiveSampler {
+	s := &adaptiveSampler{
+		config:  config.withDefaults(),
+		latency: latency,
+		queue:   queue,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	s.every.Store(1)
+	go s.run()
+	return s
+}
+
+func (s *adaptiveSampler) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	rate := 1.0
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.stop:
+			return
+		}
+
+		latency := s.latency.take()
+		pressure := latency > s.config.LatencyHigh
+		fill := 0.0
+		if s.queue != nil {
+			fill = s.queue()
+			pressure = pressure || fill > s.config.QueueHigh
+		}
+
+		next := rate * 2
+		if pressure {
+			next = math.Max(rate/2, s.config.MinRate)
+		}
+		next = math.Min(next, 1)
+		if next == rate {
+			continue
+		}
+		rate = next
+		s.every.Store(int64(math.Round(1 / rate)))
+		fields := []zapcore.Field{zap.Float64("rate", rate), Duration("write_latency", latency)}
+		if s.queue != nil {
+			fields = append(fields, zap.Float64("queue_fill", fill))
+		}
+		if pressure {
+			internalLog(zapcore.WarnLevel, "Adaptive sampling rate lowered", fields...)
+		} else {
+			internalLog(zapcore.InfoLevel, "Adaptive sampling rate raised", fields...)
+		}
+	}
+}
+
+func (s *adaptiveSampler) process(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+	every := s.every.Load()
+	if every <= 1 || levelRank(ent.Level) > levelRank(*s.config.MaxLevel) {
+		return ent, fields, true
+	}
+	return ent, fields, s.seen.Add(1)%uint64(every) == 0
+}
+
+func (s *adaptiveSampler) Close() error {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	<-s.done
+	return nil
+}
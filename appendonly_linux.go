@@ -0,0 +1,36 @@
+//go:build linux
+
+package logger
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// fsAppendFl is FS_APPEND_FL of linux/fs.h
+const fsAppendFl = 0x20
+
+// setAppendOnly sets or clears the append-only flag of path
+func setAppendOnly(path string, on bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fd := int(f.Fd())
+	flags, err := unix.IoctlGetUint32(fd, unix.FS_IOC_GETFLAGS)
+	if err != nil {
+		return err
+	}
+	if on == (flags&fsAppendFl != 0) {
+		return nil
+	}
+	if on {
+		flags |= fsAppendFl
+	} else {
+		flags &^= fsAppendFl
+	}
+	return unix.IoctlSetPointerInt(fd, unix.FS_IOC_SETFLAGS, int(flags))
+}
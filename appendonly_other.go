@@ -0,0 +1,11 @@
+//go:build !linux
+
+package logger
+
+import (
+	"errors"
+)
+
+func setAppendOnly(path string, on bool) error {
+	return errors.New("Append-only log files are only supported on linux")
+}
@@ -0,0 +1,294 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ArchiveStore receives the rotated logfiles, see S3Store, GCSStore and SFTPStore
+type ArchiveStore interface {
+	// Upload stores size bytes of body under key, body is rewound on retry
+	Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) error
+}
+
+// ArchiveConfig uploads every rotated logfile to Store, instead of a cron
+// job shipping the log directory:
+//
+//	Archive: &logger.ArchiveConfig{
+//		Store:       logger.S3Store(s3.NewFromConfig(awsConfig), "acme-logs"),
+//		KeyTemplate: "svc/{host}/{date}/{file}",
+//		Compress:    true,
+//		DeleteLocal: true,
+//	}
+type ArchiveConfig struct {
+	Store ArchiveStore
+	// KeyTemplate is the key of a file, {host}, {logfile} (the Filename),
+	// {file} (the uploaded file name), {date}, {year}, {month}, {day} and
+	// {hour} (the rotation time in UTC) are replaced, default
+	// "{host}/{date}/{file}"
+	KeyTemplate string
+	// Compress gzips the files not compressed yet before the upload, the
+	// key then ends with .gz
+	Compress bool
+	// Marker uploads an empty object at the key with this suffix once the
+	// file is uploaded, e.g. ".done", none when empty
+	Marker string
+	// Retries is the number of attempts after a failed upload, default 3,
+	// waiting 1s then doubling, a file failing them all is retried at the
+	// next rotation
+	Retries int
+	// Timeout bounds an upload attempt, default 5 minutes
+	Timeout time.Duration
+	// DeleteLocal removes the files once uploaded
+	DeleteLocal bool
+	// OnUploaded is called after every uploaded file
+	OnUploaded func(file, key string)
+	// OnFailed is called when a file failed every attempt
+	OnFailed func(file string, err error)
+}
+
+func (c ArchiveConfig) withDefaults() ArchiveConfig {
+	if c.KeyTemplate == "" {
+		c.KeyTemplate = "{host}/{date}/{file}"
+	}
+	if c.Retries == 0 {
+		c.Retries = 3
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 5 * time.Minute
+	}
+	return c
+}
+
+// archiveKey expands the key template for the file rotated at rotated
+func archiveKey(tmpl, logfile, file string, rotated time.Time) string {
+	host, _ := os.Hostname()
+	rotated = rotated.UTC()
+	return strings.NewReplacer(
+		"{host}", host,
+		"{logfile}", logfile,
+		"{file}", file,
+		"{date}", rotated.Format("2006-01-02"),
+		"{year}", rotated.Format("2006"),
+		"{month}", rotated.Format("01"),
+		"{day}", rotated.Format("02"),
+		"{hour}", rotated.Format("15"),
+	).Replace(tmpl)
+}
+
+// archivedPath lists the files of the logfile name already uploaded
+func archivedPath(dir, name string) string {
+	return filepath.Join(dir, name+".archived")
+}
+
+func readArchived(path string) (map[string]bool, error) {
+	archived := map[string]bool{}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return archived, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		archived[scanner.Text()] = true
+	}
+	return archived, scanner.Err()
+}
+
+var (
+	archiveMu   sync.Mutex
+	archiveStop chan struct{}
+	// archiveWake asks for the rotated files to be uploaded
+	archiveWake = make(chan struct{}, 1)
+)
+
+// startArchive replaces the running archiver, it uploads the rotated files
+// not uploaded yet right away, after every rotation and every minute
+func startArchive(config Config) {
+	archiveMu.Lock()
+	defer archiveMu.Unlock()
+
+	if archiveStop != nil {
+		close(archiveStop)
+		archiveStop = nil
+	}
+	if config.Archive == nil || config.Archive.Store == nil || !config.FileLoggingEnabled || config.Filename == "" {
+		return
+	}
+	archive := config.Archive.withDefaults()
+	dir := config.Directory
+	if dir == "" {
+		dir = "."
+	}
+
+	stop := make(chan struct{})
+	archiveStop = stop
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer cancel()
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			for _, name := range retainedLogfiles(config) {
+				if err := archiveRotated(ctx, archive, dir, name); err != nil && ctx.Err() == nil {
+					internalLog(zapcore.WarnLevel, "Failed archive rotated log files", String("file", name), Err(err))
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-archiveWake:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	go func() {
+		<-stop
+		cancel()
+	}()
+}
+
+// archiveRotated uploads the rotated files of name not uploaded yet
+func archiveRotated(ctx context.Context, archive ArchiveConfig, dir, name string) error {
+	statePath := archivedPath(dir, name)
+	archived, err := readArchived(statePath)
+	if err != nil {
+		return err
+	}
+	files, err := rotatedFiles(dir, name)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		base := filepath.Base(f.Path)
+		if archived[base] {
+			continue
+		}
+		key, err := archiveFile(ctx, archive, name, f)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			internalLog(zapcore.ErrorLevel, "Failed upload rotated log file", String("file", f.Path), Err(err))
+			if archive.OnFailed != nil {
+				archive.OnFailed(f.Path, err)
+			}
+			continue
+		}
+		if err := appendLine(statePath, base); err != nil {
+			return err
+		}
+		internalLog(zapcore.InfoLevel, "Rotated log file archived", String("file", f.Path), String("key", key))
+		if archive.OnUploaded != nil {
+			archive.OnUploaded(f.Path, key)
+		}
+		if archive.DeleteLocal {
+			if err := os.Remove(f.Path); err != nil {
+				internalLog(zapcore.WarnLevel, "Failed delete archived log file", String("file", f.Path), Err(err))
+			}
+		}
+	}
+	return nil
+}
+
+// archiveFile uploads one file with its marker, returning its key
+func archiveFile(ctx context.Context, archive ArchiveConfig, logfile string, f RetainedFile) (string, error) {
+	file := filepath.Base(f.Path)
+	body, err := os.Open(f.Path)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	upload := body
+	if archive.Compress && !isGzipPath(f.Path) {
+		if upload, err = gzipTemp(body); err != nil {
+			return "", err
+		}
+		defer os.Remove(upload.Name())
+		defer upload.Close()
+		file += ".gz"
+	}
+	info, err := upload.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	key := archiveKey(archive.KeyTemplate, logfile, file, f.Rotated)
+	if err := uploadWithRetry(ctx, archive, key, upload, info.Size()); err != nil {
+		return key, err
+	}
+	if archive.Marker != "" {
+		if err := uploadWithRetry(ctx, archive, key+archive.Marker, bytes.NewReader(nil), 0); err != nil {
+			return key, err
+		}
+	}
+	return key, nil
+}
+
+func uploadWithRetry(ctx context.Context, archive ArchiveConfig, key string, body io.ReadSeeker, size int64) error {
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		uploadCtx, cancel := context.WithTimeout(ctx, archive.Timeout)
+		err := archive.Store.Upload(uploadCtx, key, body, size)
+		cancel()
+		if err == nil || attempt >= archive.Retries || ctx.Err() != nil {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// gzipTemp compresses r into a temporary file, removed by the caller
+func gzipTemp(r io.Reader) (*os.File, error) {
+	tmp, err := os.CreateTemp("", "logger-archive-*.gz")
+	if err != nil {
+		return nil, err
+	}
+	zw := gzip.NewWriter(tmp)
+	_, err = io.Copy(zw, r)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return tmp, nil
+}
+
+func appendLine(path, line string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(line + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"context"
+	"io"
+
+	"cloud.google.com/go/storage"
+)
+
+type gcsStore struct {
+	client *storage.Client
+	bucket string
+}
+
+// GCSStore uploads the archived files into bucket with client
+func GCSStore(client *storage.Client, bucket string) ArchiveStore {
+	return gcsStore{client: client, bucket: bucket}
+}
+
+func (s gcsStore) Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	// canceling the context aborts the object on failure
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := s.client.Bucket(s.bucket).Object(key).NewWriter(ctx)
+	if _, err := io.Copy(w, body); err != nil {
+		cancel()
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
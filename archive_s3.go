@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type s3Store struct {
+	client *s3.Client
+	bucket string
+}
+
+// S3Store uploads the archived files into bucket with client
+func S3Store(client *s3.Client, bucket string) ArchiveStore {
+	return s3Store{client: client, bucket: bucket}
+}
+
+func (s s3Store) Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+	})
+	return err
+}
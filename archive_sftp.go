@@ -0,0 +1,185 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SFTPConfig configures SFTPStore, for hosts that cannot reach an object
+// store. The host key is always verified.
+type SFTPConfig struct {
+	// Address is host:port of the server
+	Address string
+	User    string
+	// Auth lists the ssh authentication methods, e.g. ssh.PublicKeys(signer)
+	Auth []ssh.AuthMethod
+	// KnownHosts is the known_hosts file holding the server key, used
+	// when HostKey is nil
+	KnownHosts string
+	// HostKey is the expected server key
+	HostKey ssh.PublicKey
+	// Directory is prepended to the keys, default the login directory
+	Directory string
+	// BytesPerSecond caps the upload bandwidth, unlimited when 0
+	BytesPerSecond int64
+	// DialTimeout bounds the connection, default 10s
+	DialTimeout time.Duration
+}
+
+type sftpStore struct {
+	config SFTPConfig
+	ssh    *ssh.ClientConfig
+
+	mu     sync.Mutex
+	conn   *ssh.Client
+	client *sftp.Client
+}
+
+// SFTPStore uploads the archived files to an SFTP server, files are
+// written under a .part name and renamed once complete. The connection is
+// opened on the first upload and reopened after a failure.
+func SFTPStore(config SFTPConfig) (ArchiveStore, error) {
+	if config.Address == "" || config.User == "" {
+		return nil, errors.New("Bad sftp address or user")
+	}
+	var hostKey ssh.HostKeyCallback
+	switch {
+	case config.HostKey != nil:
+		hostKey = ssh.FixedHostKey(config.HostKey)
+	case config.KnownHosts != "":
+		callback, err := knownhosts.New(config.KnownHosts)
+		if err != nil {
+			return nil, err
+		}
+		hostKey = callback
+	default:
+		return nil, errors.New("Missing sftp host key or known hosts file")
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = 10 * time.Second
+	}
+	return &sftpStore{
+		config: config,
+		ssh: &ssh.ClientConfig{
+			User:            config.User,
+			Auth:            config.Auth,
+			HostKeyCallback: hostKey,
+			Timeout:         config.DialTimeout,
+		},
+	}, nil
+}
+
+func (s *sftpStore) connect() (*sftp.Client, error) {
+	if s.client != nil {
+		return s.client, nil
+	}
+	conn, err := ssh.Dial("tcp", s.config.Address, s.ssh)
+	if err != nil {
+		return nil, err
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	s.conn, s.client = conn, client
+	return client, nil
+}
+
+func (s *sftpStore) disconnect() {
+	if s.client != nil {
+		s.client.Close()
+		s.conn.Close()
+		s.client, s.conn = nil, nil
+	}
+}
+
+func (s *sftpStore) Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client, err := s.connect()
+	if err != nil {
+		return err
+	}
+	// the server is unreachable past the deadline, drop the connection
+	done := make(chan struct{})
+	defer close(done)
+	conn := s.conn
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	err = s.upload(ctx, client, key, body)
+	if err != nil {
+		s.disconnect()
+	}
+	return err
+}
+
+func (s *sftpStore) upload(ctx context.Context, client *sftp.Client, key string, body io.Reader) error {
+	target := key
+	if s.config.Directory != "" {
+		target = path.Join(s.config.Directory, key)
+	}
+	if err := client.MkdirAll(path.Dir(target)); err != nil {
+		return err
+	}
+	part := target + ".part"
+	f, err := client.Create(part)
+	if err != nil {
+		return err
+	}
+	var r io.Reader = body
+	if s.config.BytesPerSecond > 0 {
+		r = &throttledReader{ctx: ctx, r: body, rate: s.config.BytesPerSecond, start: time.Now()}
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		client.Remove(part)
+		return err
+	}
+	return client.PosixRename(part, target)
+}
+
+// throttledReader sleeps to keep the average rate under rate bytes per second
+type throttledReader struct {
+	ctx   context.Context
+	r     io.Reader
+	rate  int64
+	start time.Time
+	read  int64
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	// small reads keep the rate even
+	if max := int(t.rate/10) + 1; len(p) > max {
+		p = p[:max]
+	}
+	n, err := t.r.Read(p)
+	t.read += int64(n)
+	due := t.start.Add(time.Duration(float64(t.read) / float64(t.rate) * float64(time.Second)))
+	if wait := time.Until(due); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-t.ctx.Done():
+			return n, t.ctx.Err()
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,313 @@
+package logger
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// AsyncConfig moves encoding and writing off the calling goroutine
+type AsyncConfig struct {
+	// QueueSize is the number of queued entries, further entries are dropped, default 8192
+	QueueSize int
+	// DropReportInterval is how often a summary of the dropped entries is
+	// logged, default 10s
+	DropReportInterval time.Duration
+}
+
+type asyncEntry struct {
+	core   zapcore.Core
+	ent    zapcore.Entry
+	fields *[]zapcore.Field
+	flush  chan struct{}
+}
+
+// entryQueue is the bounded queue between the logging goroutines and the
+// writer goroutine, push never blocks and reports false when full
+type entryQueue interface {
+	push(e asyncEntry) bool
+	// popBatch appends up to cap(dst) queued entries to dst without blocking
+	popBatch(dst []asyncEntry) []asyncEntry
+	// len and cap of the queue, len may be stale
+	len() int
+	cap() int
+}
+
+// chanQueue is the default entryQueue
+type chanQueue chan asyncEntry
+
+func (q chanQueue) push(e asyncEntry) bool {
+	select {
+	case q <- e:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q chanQueue) len() int { return len(q) }
+
+func (q chanQueue) cap() int { return cap(q) }
+
+func (q chanQueue) popBatch(dst []asyncEntry) []asyncEntry {
+	for len(dst) < cap(dst) {
+		select {
+		case e := <-q:
+			dst = append(dst, e)
+		default:
+			return dst
+		}
+	}
+	return dst
+}
+
+// asyncQueue is shared by an async core and every core derived by With
+type asyncQueue struct {
+	root     zapcore.Core
+	queue    entryQueue
+	batch    int
+	interval time.Duration
+	// sleeping is set while the writer waits for wake
+	sleeping atomic.Bool
+	// warned is when the queue pressure was last reported, unix nanoseconds
+	warned  atomic.Int64
+	wake    chan struct{}
+	mu      sync.Mutex
+	dropped map[zapcore.Level]uint64
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+type asyncCore struct {
+	zapcore.Core
+	q *asyncQueue
+}
+
+func newAsyncCore(core zapcore.Core, config AsyncConfig, engine string) *asyncCore {
+	if config.QueueSize <= 0 {
+		config.QueueSize = 8192
+	}
+	if config.DropReportInterval <= 0 {
+		config.DropReportInterval = 10 * time.Second
+	}
+
+	q := &asyncQueue{
+		root:     core,
+		batch:    256,
+		interval: config.DropReportInterval,
+		wake:     make(chan struct{}, 1),
+		dropped:  make(map[zapcore.Level]uint64),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if engine == EngineRing {
+		q.queue = newRingQueue(config.QueueSize)
+	} else {
+		q.queue = make(chanQueue, config.QueueSize)
+	}
+	go q.run()
+
+	return &asyncCore{Core: core, q: q}
+}
+
+func (c *asyncCore) With(fields []zapcore.Field) zapcore.Core {
+	return &asyncCore{Core: c.Core.With(fields), q: c.q}
+}
+
+func (c *asyncCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *asyncCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	// the process may exit right after panic and fatal entries, write them in place
+	if levelAtLeast(ent.Level, zapcore.DPanicLevel) {
+		c.q.flush()
+		writeEntry(c.Core, ent, fields)
+		return nil
+	}
+
+	e := asyncEntry{core: c.Core, ent: ent, fields: getFields(fields, 0)}
+	if !c.q.push(e) {
+		putFields(e.fields)
+		c.q.drop(ent.Level)
+	}
+	return nil
+}
+
+func (c *asyncCore) Sync() error {
+	c.q.flush()
+	return c.Core.Sync()
+}
+
+func (c *asyncCore) Close() error {
+	return c.q.Close()
+}
+
+func writeEntry(core zapcore.Core, ent zapcore.Entry, fields []zapcore.Field) {
+	if ce := core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+}
+
+func (q *asyncQueue) push(e asyncEntry) bool {
+	if !q.queue.push(e) {
+		return false
+	}
+	if n := q.queue.len(); n >= q.queue.cap()*8/10 {
+		q.pressure(n)
+	}
+	if q.sleeping.Load() {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+func (q *asyncQueue) run() {
+	defer close(q.done)
+
+	ticker := time.NewTicker(q.interval)
+	defer ticker.Stop()
+	batch := make([]asyncEntry, 0, q.batch)
+	for {
+		if batch = q.handleBatch(batch); len(batch) > 0 {
+			select {
+			case <-ticker.C:
+				q.report()
+			default:
+			}
+			continue
+		}
+
+		// check again once sleeping is visible, a push in between would
+		// otherwise not wake the writer
+		q.sleeping.Store(true)
+		if batch = q.handleBatch(batch); len(batch) > 0 {
+			q.sleeping.Store(false)
+			continue
+		}
+		select {
+		case <-q.wake:
+		case <-ticker.C:
+			q.report()
+		case <-q.stop:
+			for len(q.handleBatch(batch)) > 0 {
+			}
+			q.report()
+			return
+		}
+		q.sleeping.Store(false)
+	}
+}
+
+// handleBatch writes the next batch of queued entries and returns it
+func (q *asyncQueue) handleBatch(batch []asyncEntry) []asyncEntry {
+	batch = q.queue.popBatch(batch[:0])
+	for i := range batch {
+		q.handle(batch[i])
+		batch[i] = asyncEntry{}
+	}
+	return batch
+}
+
+func (q *asyncQueue) handle(e asyncEntry) {
+	if e.flush != nil {
+		close(e.flush)
+		return
+	}
+	writeEntry(e.core, e.ent, *e.fields)
+	putFields(e.fields)
+}
+
+// pressure reports a queue at least 80% full, at most once per report interval
+func (q *asyncQueue) pressure(n int) {
+	now := time.Now().UnixNano()
+	last := q.warned.Load()
+	if now-last < int64(q.interval) || !q.warned.CompareAndSwap(last, now) {
+		return
+	}
+	internalLog(zapcore.WarnLevel, "Log queue 80% full", Int("queued", n), Int("size", q.queue.cap()))
+}
+
+func (q *asyncQueue) drop(level zapcore.Level) {
+	q.mu.Lock()
+	q.dropped[level]++
+	q.mu.Unlock()
+}
+
+// takeDropped returns and resets the drop counters
+func (q *asyncQueue) takeDropped() map[zapcore.Level]uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.dropped) == 0 {
+		return nil
+	}
+	dropped := q.dropped
+	q.dropped = make(map[zapcore.Level]uint64)
+	return dropped
+}
+
+// report logs how many entries of each level were dropped since the last report
+func (q *asyncQueue) report() {
+	dropped := q.takeDropped()
+	if dropped == nil {
+		return
+	}
+
+	levels := make([]zapcore.Level, 0, len(dropped))
+	var total uint64
+	for level, n := range dropped {
+		levels = append(levels, level)
+		total += n
+	}
+	sort.Slice(levels, func(i, j int) bool { return levelRank(levels[i]) < levelRank(levels[j]) })
+
+	ent := zapcore.Entry{Level: zapcore.WarnLevel, Time: time.Now(), Message: "log entries dropped"}
+	writeEntry(q.root, ent, []zapcore.Field{
+		Object("dropped", func(enc ObjectEncoder) error {
+			for _, level := range levels {
+				enc.AddUint64(levelName(level), dropped[level])
+			}
+			return nil
+		}),
+		Int64("dropped_total", int64(total)),
+		Duration("window", q.interval),
+	})
+}
+
+// flush blocks until the entries queued so far are written
+func (q *asyncQueue) flush() {
+	e := asyncEntry{flush: make(chan struct{})}
+	for !q.push(e) {
+		select {
+		case <-q.done:
+			return
+		default:
+			runtime.Gosched()
+		}
+	}
+	select {
+	case <-e.flush:
+	case <-q.done:
+	}
+}
+
+func (q *asyncQueue) Close() error {
+	select {
+	case <-q.stop:
+	default:
+		close(q.stop)
+	}
+	<-q.done
+	return q.root.Sync()
+}
@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Environments told apart by DetectEnvironment
+const (
+	// EnvContainer is a docker, podman, containerd or kubernetes container
+	EnvContainer = "container"
+	// EnvSystemd is a service started by systemd with the journal running
+	EnvSystemd = "systemd"
+	// EnvHost is anything else
+	EnvHost = "host"
+)
+
+// cgroupMarkers are found in /proc/1/cgroup inside containers
+var cgroupMarkers = []string{"docker", "kubepods", "containerd", "libpod", "lxc", "ecs"}
+
+// DetectEnvironment tells where the process runs from the environment
+// variables, the marker files and the cgroups set by the runtimes
+func DetectEnvironment() string {
+	if inContainer() {
+		return EnvContainer
+	}
+	if os.Getenv("INVOCATION_ID") != "" || os.Getenv("JOURNAL_STREAM") != "" {
+		if _, err := os.Stat(defaultJournalSocket); err == nil {
+			return EnvSystemd
+		}
+	}
+	return EnvHost
+}
+
+func inContainer() bool {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" || os.Getenv("container") != "" {
+		return true
+	}
+	for _, marker := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(marker); err == nil {
+			return true
+		}
+	}
+	data, err := os.ReadFile("/proc/1/cgroup")
+	if err != nil {
+		return false
+	}
+	cgroup := string(data)
+	for _, marker := range cgroupMarkers {
+		if strings.Contains(cgroup, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// AutoDetectConfig is the Config of AutoDetect for env: JSON entries on
+// stdout in a container, the journal alone under systemd, and
+// ProductionConfig writing to logs/<program>.log on a bare host
+func AutoDetectConfig(env string) Config {
+	switch env {
+	case EnvContainer:
+		return Config{Encoding: EncodingJSON}
+	case EnvSystemd:
+		// stdout of a service goes to the journal too
+		return Config{
+			DisableStdout: true,
+			Sinks:         []SinkConfig{{Type: SinkJournald}},
+		}
+	}
+	config, _ := ProductionConfig(filepath.Join("logs", filepath.Base(os.Args[0])+".log"))
+	return config
+}
+
+// AutoDetect configures the package for the environment found by
+// DetectEnvironment at the info level:
+//
+//	log := logger.AutoDetect()
+//
+// Under systemd it falls back to stdout when the journal cannot be reached.
+func AutoDetect() Log {
+	env := DetectEnvironment()
+	if env == EnvSystemd {
+		if w, err := newJournaldWriter(JournaldConfig{}); err != nil {
+			env = EnvContainer
+		} else {
+			w.Close()
+		}
+	}
+	config := AutoDetectConfig(env)
+	SetLogLevel("info")
+	Configure(config)
+	return Log{}
+}
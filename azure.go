@@ -0,0 +1,176 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// AzureMonitorConfig configures the Azure Monitor sink, entries are sent
+// in batches to the HTTP Data Collector API of a Log Analytics workspace
+// and land in the <LogType>_CL table
+type AzureMonitorConfig struct {
+	// WorkspaceID is the workspace (customer) ID
+	WorkspaceID string
+	// SharedKey is the base64 primary or secondary key of the workspace
+	SharedKey string
+	// LogType names the custom log, letters, digits and underscores
+	LogType string
+	// ResourceID links the entries to an Azure resource, optional
+	ResourceID string
+	// Endpoint overrides https://<WorkspaceID>.ods.opinsights.azure.com,
+	// e.g. for sovereign clouds
+	Endpoint string
+	// BatchSize is the number of entries per request, default 500
+	BatchSize int
+	// FlushInterval is the max time entries wait before a request, default 5s
+	FlushInterval time.Duration
+	// Client defaults to a client with a 30s timeout
+	Client *http.Client
+}
+
+// azureTimeField is the record field Azure reads TimeGenerated from
+const azureTimeField = "time"
+
+type azureWriter struct {
+	config AzureMonitorConfig
+	key    []byte
+	url    string
+	client *http.Client
+	batch  *batcher[json.RawMessage]
+
+	mu sync.Mutex
+	// skew is the server clock minus the local clock, learnt from the
+	// Date of a rejected request, signatures expire after 15 minutes
+	skew time.Duration
+}
+
+var azureClient = &http.Client{Timeout: 30 * time.Second}
+
+func newAzureWriter(config AzureMonitorConfig) (*azureWriter, error) {
+	if config.WorkspaceID == "" || config.LogType == "" {
+		return nil, errors.New("Bad azure monitor workspace or log type")
+	}
+	key, err := base64.StdEncoding.DecodeString(config.SharedKey)
+	if err != nil {
+		return nil, errors.New("Bad azure monitor shared key")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://" + config.WorkspaceID + ".ods.opinsights.azure.com"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	w := &azureWriter{
+		config: config,
+		key:    key,
+		url:    config.Endpoint + "/api/logs?api-version=2016-04-01",
+		client: config.Client,
+	}
+	if w.client == nil {
+		w.client = azureClient
+	}
+	w.batch = newBatcher("azure", config.BatchSize, config.FlushInterval, w.send)
+	return w, nil
+}
+
+func (w *azureWriter) WriteEntry(ent zapcore.Entry, fields []zapcore.Field, encoded []byte) error {
+	record := fieldMap(fields)
+	record[azureTimeField] = ent.Time.UTC().Format(time.RFC3339Nano)
+	record["level"] = levelName(ent.Level)
+	record["message"] = ent.Message
+	if ent.LoggerName != "" {
+		record["logger"] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		record["caller"] = ent.Caller.TrimmedPath()
+	}
+	if ent.Stack != "" {
+		record["stacktrace"] = ent.Stack
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return w.batch.Add(data)
+}
+
+// signature is the SharedKey authorization of a body sent at date
+func (w *azureWriter) signature(length int, date string) string {
+	mac := hmac.New(sha256.New, w.key)
+	mac.Write([]byte("POST\n" + strconv.Itoa(length) + "\napplication/json\nx-ms-date:" + date + "\n/api/logs"))
+	return "SharedKey " + w.config.WorkspaceID + ":" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// send posts a batch, a request rejected as unauthorized is signed again
+// once with the clock of the server
+func (w *azureWriter) send(records []json.RawMessage) error {
+	body, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	for attempt := 0; ; attempt++ {
+		w.mu.Lock()
+		now := time.Now().Add(w.skew)
+		w.mu.Unlock()
+
+		status, serverDate, err := w.post(body, now)
+		if err != nil {
+			return err
+		}
+		if status/100 == 2 {
+			return nil
+		}
+		if status == http.StatusForbidden && attempt == 0 && !serverDate.IsZero() {
+			w.mu.Lock()
+			w.skew = time.Until(serverDate)
+			w.mu.Unlock()
+			continue
+		}
+		return errors.New("Azure monitor status " + strconv.Itoa(status))
+	}
+}
+
+// post returns the status and the Date of the response
+func (w *azureWriter) post(body []byte, now time.Time) (int, time.Time, error) {
+	date := now.UTC().Format(http.TimeFormat)
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Log-Type", w.config.LogType)
+	req.Header.Set("x-ms-date", date)
+	req.Header.Set("time-generated-field", azureTimeField)
+	req.Header.Set("Authorization", w.signature(len(body), date))
+	if w.config.ResourceID != "" {
+		req.Header.Set("x-ms-AzureResourceId", w.config.ResourceID)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	resp.Body.Close()
+	serverDate, _ := http.ParseTime(resp.Header.Get("Date"))
+	return resp.StatusCode, serverDate, nil
+}
+
+func (w *azureWriter) Sync() error {
+	return w.batch.Flush()
+}
+
+func (w *azureWriter) Close() error {
+	return w.batch.Close()
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// batcher collects items and hands them to flush when size items are
+// buffered or interval elapsed, whichever comes first
+type batcher[T any] struct {
+	mu    sync.Mutex
+	items []T
+	size  int
+	flush func([]T) error
+	name  string
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+func newBatcher[T any](name string, size int, interval time.Duration, flush func([]T) error) *batcher[T] {
+	if size <= 0 {
+		size = 1000
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	b := &batcher[T]{
+		items: make([]T, 0, size),
+		size:  size,
+		flush: flush,
+		name:  name,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go b.run(interval)
+
+	return b
+}
+
+func (b *batcher[T]) run(interval time.Duration) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := b.Flush(); err != nil {
+				internalLog(zapcore.ErrorLevel, "Failed flush batch", String("sink", b.name), Err(err))
+			}
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// Add buffers an item and flushes in the caller when the batch is full
+func (b *batcher[T]) Add(item T) error {
+	b.mu.Lock()
+	b.items = append(b.items, item)
+	if len(b.items) < b.size {
+		b.mu.Unlock()
+		return nil
+	}
+	items := b.take()
+	b.mu.Unlock()
+
+	return b.flush(items)
+}
+
+func (b *batcher[T]) take() []T {
+	items := b.items
+	b.items = make([]T, 0, b.size)
+	return items
+}
+
+// Flush hands over whatever is buffered
+func (b *batcher[T]) Flush() error {
+	b.mu.Lock()
+	if len(b.items) == 0 {
+		b.mu.Unlock()
+		return nil
+	}
+	items := b.take()
+	b.mu.Unlock()
+
+	return b.flush(items)
+}
+
+// Close stops the timer and flushes the remaining items
+func (b *batcher[T]) Close() error {
+	select {
+	case <-b.stop:
+		return nil
+	default:
+	}
+	close(b.stop)
+	<-b.done
+	return b.Flush()
+}
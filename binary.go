@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var cborDecMode, _ = cbor.DecOptions{
+	DefaultMapType: reflect.TypeOf(map[string]interface{}(nil)),
+}.DecMode()
+
+func marshalMsgpack(m map[string]interface{}) ([]byte, error) {
+	return msgpack.Marshal(m)
+}
+
+func marshalCBOR(m map[string]interface{}) ([]byte, error) {
+	return cbor.Marshal(m)
+}
+
+// Decode reads a stream of msgpack or cbor encoded entries from r and
+// writes them to w as one JSON object per line, e.g.
+//
+//	logger.Decode(os.Stdin, os.Stdout, logger.EncodingMsgpack)
+func Decode(r io.Reader, w io.Writer, encoding string) error {
+	var next func() (interface{}, error)
+	switch encoding {
+	case EncodingMsgpack:
+		dec := msgpack.NewDecoder(r)
+		next = dec.DecodeInterface
+	case EncodingCBOR:
+		dec := cborDecMode.NewDecoder(r)
+		next = func() (interface{}, error) {
+			var v interface{}
+			err := dec.Decode(&v)
+			return v, err
+		}
+	default:
+		return errors.New("Bad binary encoding")
+	}
+
+	out := json.NewEncoder(w)
+	for {
+		v, err := next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := out.Encode(v); err != nil {
+			return err
+		}
+	}
+}
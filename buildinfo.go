@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Build variables, set at link time when the binary is not built from a
+// VCS checkout, e.g.
+//
+//	go build -ldflags "-X github.com/gwtony/logger.BuildCommit=$(git rev-parse HEAD) \
+//		-X github.com/gwtony/logger.BuildTime=$(date -u +%FT%TZ)"
+var (
+	BuildCommit  string
+	BuildTime    string
+	BuildVersion string
+)
+
+var (
+	buildInfoOnce   sync.Once
+	buildInfoFields []zapcore.Field
+)
+
+// BuildInfoFields returns vcs.revision, build_time, go_version and, when
+// known, version and vcs.modified. Link time variables win over the
+// information embedded by the go tool.
+func BuildInfoFields() []zapcore.Field {
+	buildInfoOnce.Do(func() {
+		revision, buildTime, version, modified := BuildCommit, BuildTime, BuildVersion, ""
+		goVersion := runtime.Version()
+
+		if info, ok := debug.ReadBuildInfo(); ok {
+			goVersion = info.GoVersion
+			if version == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+				version = info.Main.Version
+			}
+			for _, s := range info.Settings {
+				switch s.Key {
+				case "vcs.revision":
+					if revision == "" {
+						revision = s.Value
+					}
+				case "vcs.time":
+					if buildTime == "" {
+						buildTime = s.Value
+					}
+				case "vcs.modified":
+					modified = s.Value
+				}
+			}
+		}
+
+		buildInfoFields = []zapcore.Field{
+			String("vcs.revision", revision),
+			String("build_time", buildTime),
+			String("go_version", goVersion),
+		}
+		if version != "" {
+			buildInfoFields = append(buildInfoFields, String("version", version))
+		}
+		if modified != "" {
+			buildInfoFields = append(buildInfoFields, String("vcs.modified", modified))
+		}
+	})
+
+	return buildInfoFields
+}
+
+// BuildInfo logs a startup banner entry with the build information
+func (l *Log) BuildInfo() {
+	if ce := l.zap().Check(zapcore.InfoLevel, "build info"); ce != nil {
+		l.write(ce, BuildInfoFields())
+	}
+}
@@ -0,0 +1,215 @@
+package logger
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SupportBundleOptions configures BuildSupportBundle
+type SupportBundleOptions struct {
+	// MaxFileBytes bounds every logfile copied, their tail is kept,
+	// default 10MB
+	MaxFileBytes int64
+	// Env lists the environment variables included, the LOG_ ones always
+	// are, secrets are masked like in DumpConfig
+	Env []string
+	// Captures adds the entries of these capture sessions
+	Captures []*Capture
+}
+
+// BuildSupportBundle writes a zip into dir and returns its path, it holds
+//
+//	config.json       DumpConfig
+//	recent.jsonl      RecentEntries
+//	environment.json  host, process, build and environment facts
+//	logs/             the tail of the logfile and of its latest backup
+//	captures/N/       the entries of opts.Captures
+func BuildSupportBundle(dir string, opts SupportBundleOptions) (string, error) {
+	if opts.MaxFileBytes <= 0 {
+		opts.MaxFileBytes = 10 << 20
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	hostname, _ := os.Hostname()
+	path := filepath.Join(dir, "support-"+hostname+"-"+time.Now().UTC().Format("20060102T150405Z")+".zip")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	if err := writeZipJSON(zw, "config.json", DumpConfig()); err != nil {
+		return "", err
+	}
+	if err := writeZipJSON(zw, "environment.json", environmentFacts(opts.Env)); err != nil {
+		return "", err
+	}
+	w, err := zw.Create("recent.jsonl")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range RecentEntries() {
+		if _, err := w.Write(line); err != nil {
+			return "", err
+		}
+	}
+
+	config := currentConfig()
+	if config.FileLoggingEnabled {
+		active := filepath.Join(defaultString(config.Directory, "."), config.Filename)
+		for _, file := range []string{active, latestBackup(active)} {
+			if file == "" {
+				continue
+			}
+			if err := addFileTail(zw, "logs/"+filepath.Base(file), file, opts.MaxFileBytes); err != nil {
+				internalLog(zapcore.ErrorLevel, "Failed add logfile to support bundle", String("file", file), Err(err))
+			}
+		}
+	}
+	for i, c := range opts.Captures {
+		if err := c.addToBundle(zw, "captures/"+strconv.Itoa(i)+"/"); err != nil {
+			return "", err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return path, f.Sync()
+}
+
+func environmentFacts(names []string) map[string]interface{} {
+	facts := fieldMap(append(hostFields(), BuildInfoFields()...))
+	facts["go_runtime"] = runtime.Version()
+	facts["goroutines"] = runtime.NumGoroutine()
+	facts["uptime"] = time.Since(processStart).String()
+	facts["args"] = maskArgs(os.Args)
+
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, "LOG_") && !contains(names, name) {
+			continue
+		}
+		if secretField(strings.ReplaceAll(name, "_", "")) {
+			value = "****"
+		}
+		env[name] = value
+	}
+	facts["env"] = env
+	return facts
+}
+
+// latestBackup finds the newest file rolled from active with the
+// lumberjack naming, name-<time>.ext possibly compressed, "" when none
+func latestBackup(active string) string {
+	ext := filepath.Ext(active)
+	prefix := strings.TrimSuffix(active, ext) + "-"
+	matches, _ := filepath.Glob(prefix + "*" + ext + "*")
+
+	latest := ""
+	var latestTime time.Time
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if latest == "" || info.ModTime().After(latestTime) {
+			latest, latestTime = m, info.ModTime()
+		}
+	}
+	return latest
+}
+
+// addFileTail copies the last max bytes of path into the zip
+func addFileTail(zw *zip.Writer, name, path string, max int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.Size() > max {
+		if _, err := f.Seek(info.Size()-max, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// maskArgs masks the command line like DumpConfig masks the config: the
+// value of the secret flags, e.g. --db-password=x, -api-token x, and the
+// password of URLs and DSNs, e.g. postgres://user:x@host/db
+func maskArgs(args []string) []string {
+	masked := make([]string, len(args))
+	secretNext := false
+	for i, arg := range args {
+		switch {
+		case secretNext:
+			arg = "****"
+			secretNext = false
+		case strings.HasPrefix(arg, "-"):
+			name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			switch {
+			case !secretFlag(name):
+				if hasValue {
+					arg = arg[:len(arg)-len(value)] + maskPassword(value)
+				}
+			case hasValue:
+				arg = arg[:len(arg)-len(value)] + "****"
+			default:
+				// the value is the next argument
+				secretNext = true
+			}
+		default:
+			arg = maskPassword(arg)
+		}
+		masked[i] = arg
+	}
+	return masked
+}
+
+// secretFlag reports whether the flag name holds a secret, tokens
+// included
+func secretFlag(name string) bool {
+	name = strings.NewReplacer("-", "", "_", "", ".", "").Replace(name)
+	return secretField(name) || strings.HasSuffix(strings.ToLower(name), "token")
+}
+
+// maskPassword masks the password of the user info of s, in URLs as well
+// as in DSNs like user:x@tcp(host)/db
+func maskPassword(s string) string {
+	at := strings.LastIndex(s, "@")
+	if at < 0 {
+		return s
+	}
+	start := strings.Index(s, "://")
+	if start >= 0 && start < at {
+		start += len("://")
+	} else {
+		start = 0
+	}
+	colon := strings.Index(s[start:at], ":")
+	if colon < 0 {
+		return s
+	}
+	return s[:start+colon+1] + "****" + s[at:]
+}
@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMaskArgs(t *testing.T) {
+	args := []string{
+		"/usr/bin/app",
+		"--db-password=hunter2",
+		"-api-token", "abc",
+		"--listen=:8080",
+		"--dsn=postgres://app:hunter2@db:5432/app",
+		"app:hunter2@tcp(db:3306)/app",
+		"serve",
+	}
+	want := []string{
+		"/usr/bin/app",
+		"--db-password=****",
+		"-api-token", "****",
+		"--listen=:8080",
+		"--dsn=postgres://app:****@db:5432/app",
+		"app:****@tcp(db:3306)/app",
+		"serve",
+	}
+	if got := maskArgs(args); !reflect.DeepEqual(got, want) {
+		t.Errorf("maskArgs\n got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// CanonicalMessage is the message of the canonical log lines
+const CanonicalMessage = "canonical-log-line"
+
+// canonicalLine accumulates the fields of one request
+type canonicalLine struct {
+	mu      sync.Mutex
+	start   time.Time
+	fields  []zapcore.Field
+	emitted bool
+}
+
+type canonicalKey struct{}
+
+// BeginCanonical returns a copy of ctx collecting the fields of a
+// canonical log line, the single summary entry of a request logged by
+// EmitCanonical:
+//
+//	ctx = logger.BeginCanonical(r.Context())
+//	defer logger.EmitCanonical(ctx)
+//	...
+//	logger.AddCanonical(ctx, logger.String("user_id", id), logger.Int("rows", n))
+func BeginCanonical(ctx context.Context) context.Context {
+	return context.WithValue(ctx, canonicalKey{}, &canonicalLine{start: time.Now()})
+}
+
+func canonicalLineOf(ctx context.Context) *canonicalLine {
+	c, _ := ctx.Value(canonicalKey{}).(*canonicalLine)
+	return c
+}
+
+// AddCanonical adds fields to the canonical log line of ctx, a later
+// field of the same key replaces the earlier one. Nothing happens without
+// BeginCanonical.
+func AddCanonical(ctx context.Context, fields ...zapcore.Field) {
+	c := canonicalLineOf(ctx)
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.fields = append(c.fields, fields...)
+	c.mu.Unlock()
+}
+
+// EmitCanonical logs the canonical log line of ctx at the info level with
+// its duration and the Log.Ctx fields, once, later calls do nothing
+func EmitCanonical(ctx context.Context) {
+	c := canonicalLineOf(ctx)
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	if c.emitted {
+		c.mu.Unlock()
+		return
+	}
+	c.emitted = true
+	fields := lastFieldPerKey(c.fields)
+	c.mu.Unlock()
+
+	fields = append(fields, Duration("duration", time.Since(c.start)))
+	log := Log{ctx: ctx}
+	if ce := log.zap().Check(zapcore.InfoLevel, CanonicalMessage); ce != nil {
+		log.write(ce, fields)
+	}
+}
+
+// lastFieldPerKey keeps the last field of every key, in the order the
+// keys were first added
+func lastFieldPerKey(fields []zapcore.Field) []zapcore.Field {
+	index := make(map[string]int, len(fields))
+	out := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		if i, ok := index[f.Key]; ok {
+			out[i] = f
+			continue
+		}
+		index[f.Key] = len(out)
+		out = append(out, f)
+	}
+	return out
+}
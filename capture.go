@@ -0,0 +1,260 @@
+package logger
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// CaptureOptions selects the entries of a capture session
+type CaptureOptions struct {
+	// Field and Value capture the entries carrying the field with this
+	// value, e.g. {"request_id", id}
+	Field string
+	Value string
+	// Match captures the entries it returns true for, it is called
+	// with the fields added by With included, every entry matches when
+	// both Match and Field are unset
+	Match func(ent zapcore.Entry, fields []zapcore.Field) bool
+	// Duration bounds the session, default 10 minutes
+	Duration time.Duration
+	// MaxEntries bounds the entries kept in memory, default 10000, later
+	// entries are counted as dropped
+	MaxEntries int
+	// File also appends the captured entries to this file when set
+	File string
+	// Redact replaces the value of these fields by "[REDACTED]" on top of
+	// the registered transformers
+	Redact []string
+}
+
+// Capture holds the entries of a session started by CaptureSession
+type Capture struct {
+	opts    CaptureOptions
+	enc     zapcore.Encoder
+	redact  Transformer
+	started time.Time
+
+	mu      sync.Mutex
+	entries [][]byte
+	dropped int
+	ended   time.Time
+	file    *os.File
+
+	done chan struct{}
+	once sync.Once
+}
+
+var captures = struct {
+	mu   sync.RWMutex
+	list []*Capture
+	// count of sessions running, entries skip the sessions while it is 0
+	count atomic.Int32
+}{}
+
+// CaptureSession copies the entries matching opts, as JSON and after the
+// registered transformers, until opts.Duration elapses, ctx is done or
+// Stop is called. The entries still go to the configured outputs.
+//
+//	c, _ := logger.CaptureSession(ctx, logger.CaptureOptions{Field: "request_id", Value: id})
+//	defer c.Stop()
+//	...
+//	c.WriteBundle(w)
+func CaptureSession(ctx context.Context, opts CaptureOptions) (*Capture, error) {
+	if opts.Duration <= 0 {
+		opts.Duration = 10 * time.Minute
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 10000
+	}
+	c := &Capture{
+		opts:    opts,
+		enc:     zapcore.NewJSONEncoder(newEncoderConfig(*currentConfig())),
+		started: time.Now(),
+		done:    make(chan struct{}),
+	}
+	if len(opts.Redact) > 0 {
+		c.redact = RedactFields(opts.Redact...)
+	}
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, err
+		}
+		c.file = f
+	}
+
+	captures.mu.Lock()
+	captures.list = append(captures.list, c)
+	captures.count.Store(int32(len(captures.list)))
+	captures.mu.Unlock()
+
+	go func() {
+		t := time.NewTimer(opts.Duration)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+		case <-t.C:
+		case <-c.done:
+		}
+		c.Stop()
+	}()
+	return c, nil
+}
+
+// Stop ends the session, the captured entries stay available
+func (c *Capture) Stop() {
+	c.once.Do(func() {
+		captures.mu.Lock()
+		list := make([]*Capture, 0, len(captures.list))
+		for _, other := range captures.list {
+			if other != c {
+				list = append(list, other)
+			}
+		}
+		captures.list = list
+		captures.count.Store(int32(len(list)))
+		captures.mu.Unlock()
+
+		c.mu.Lock()
+		c.ended = time.Now()
+		if c.file != nil {
+			c.file.Close()
+			c.file = nil
+		}
+		c.mu.Unlock()
+		close(c.done)
+	})
+}
+
+// Done is closed once the session ends
+func (c *Capture) Done() <-chan struct{} {
+	return c.done
+}
+
+// Entries returns the captured entries, one JSON object per line
+func (c *Capture) Entries() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]byte(nil), c.entries...)
+}
+
+// WriteBundle writes a zip holding the captured entries as entries.jsonl,
+// the session as capture.json and DumpConfig as config.json
+func (c *Capture) WriteBundle(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	if err := c.addToBundle(zw, ""); err != nil {
+		return err
+	}
+	if err := writeZipJSON(zw, "config.json", DumpConfig()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// addToBundle writes entries.jsonl and capture.json under dir
+func (c *Capture) addToBundle(zw *zip.Writer, dir string) error {
+	c.mu.Lock()
+	entries := append([][]byte(nil), c.entries...)
+	info := map[string]interface{}{
+		"field":    c.opts.Field,
+		"value":    c.opts.Value,
+		"started":  c.started.Format(time.RFC3339Nano),
+		"entries":  len(c.entries),
+		"dropped":  c.dropped,
+		"duration": c.opts.Duration.String(),
+	}
+	if !c.ended.IsZero() {
+		info["ended"] = c.ended.Format(time.RFC3339Nano)
+	}
+	c.mu.Unlock()
+
+	f, err := zw.Create(dir + "entries.jsonl")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if _, err := f.Write(e); err != nil {
+			return err
+		}
+	}
+	return writeZipJSON(zw, dir+"capture.json", info)
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+func (c *Capture) match(ent zapcore.Entry, fields []zapcore.Field) bool {
+	if c.opts.Field != "" && !hasFieldValue(fields, c.opts.Field, c.opts.Value) {
+		return false
+	}
+	return c.opts.Match == nil || c.opts.Match(ent, fields)
+}
+
+func (c *Capture) add(ent zapcore.Entry, fields []zapcore.Field) {
+	if c.redact != nil {
+		fields = append([]zapcore.Field(nil), fields...)
+		ent, fields = c.redact(ent, fields)
+	}
+	buf, err := c.enc.Clone().EncodeEntry(ent, fields)
+	if err != nil {
+		return
+	}
+	line := append([]byte(nil), buf.Bytes()...)
+	buf.Free()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.ended.IsZero() {
+		return
+	}
+	if c.file != nil {
+		c.file.Write(line)
+	}
+	if len(c.entries) >= c.opts.MaxEntries {
+		c.dropped++
+		return
+	}
+	c.entries = append(c.entries, line)
+}
+
+// newCaptureProcess copies the entries to the running capture sessions
+func newCaptureProcess() processFunc {
+	return func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+		if captures.count.Load() == 0 {
+			return ent, fields, true
+		}
+		captures.mu.RLock()
+		list := captures.list
+		captures.mu.RUnlock()
+
+		all := fields
+		if len(context) > 0 {
+			all = make([]zapcore.Field, 0, len(context)+len(fields))
+			all = append(append(all, context...), fields...)
+		}
+		for _, c := range list {
+			if c.match(ent, all) {
+				c.add(ent, all)
+			}
+		}
+		return ent, fields, true
+	}
+}
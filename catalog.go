@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// MsgIDField is the key of the message ID logged by Msg
+const MsgIDField = "msg_id"
+
+// catalogMsg is a registered message, its templates by locale, "" being
+// the default one
+type catalogMsg struct {
+	level     zapcore.Level
+	templates map[string]string
+}
+
+var catalog = struct {
+	sync.RWMutex
+	msgs map[int]*catalogMsg
+}{msgs: make(map[int]*catalogMsg)}
+
+// RegisterMsg registers the message id, logged at level with the fmt
+// template rendered with the Msg arguments:
+//
+//	logger.RegisterMsg(1042, zapcore.WarnLevel, "Disk %s is %d%% full")
+//	logger.Msg(1042, "/var", 93)
+//
+// Alerts can then match msg_id 1042 whatever the wording.
+func RegisterMsg(id int, level zapcore.Level, template string) error {
+	catalog.Lock()
+	defer catalog.Unlock()
+	if _, ok := catalog.msgs[id]; ok {
+		return errors.New("Message " + strconv.Itoa(id) + " already registered")
+	}
+	catalog.msgs[id] = &catalogMsg{level: level, templates: map[string]string{"": template}}
+	return nil
+}
+
+// RegisterMsgTranslation adds the template of message id in locale, used
+// when Config.MsgLocale is locale. Explicit argument indexes, %[2]s,
+// allow a different word order.
+func RegisterMsgTranslation(id int, locale, template string) error {
+	catalog.Lock()
+	defer catalog.Unlock()
+	m, ok := catalog.msgs[id]
+	if !ok {
+		return errors.New("Message " + strconv.Itoa(id) + " not registered")
+	}
+	m.templates[locale] = template
+	return nil
+}
+
+// renderMsg returns the level and text of message id, the arguments being
+// listed when the message is unknown
+func renderMsg(id int, locale string, args []interface{}) (zapcore.Level, string) {
+	catalog.RLock()
+	m, ok := catalog.msgs[id]
+	var template string
+	if ok {
+		template, ok = m.templates[locale]
+		if !ok {
+			template = m.templates[""]
+		}
+	}
+	catalog.RUnlock()
+
+	if m == nil {
+		text := fmt.Sprintln(append([]interface{}{"Message", id}, args...)...)
+		return zapcore.InfoLevel, strings.TrimSuffix(text, "\n")
+	}
+	return m.level, fmt.Sprintf(template, args...)
+}
+
+// Msg logs the registered message id, see RegisterMsg. The arguments that
+// are fields are logged as such and left out of the template.
+func Msg(id int, args ...interface{}) {
+	l := &Log{}
+	l.msg(id, args)
+}
+
+// Msg logs the registered message id with l, see RegisterMsg
+func (l *Log) Msg(id int, args ...interface{}) {
+	l.msg(id, args)
+}
+
+func (l *Log) msg(id int, args []interface{}) {
+	fields := []zapcore.Field{Int(MsgIDField, id)}
+	values := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		if f, ok := arg.(zapcore.Field); ok {
+			fields = append(fields, f)
+			continue
+		}
+		values = append(values, arg)
+	}
+	level, text := renderMsg(id, currentConfig().MsgLocale, values)
+	// skip Msg on top of the Log method
+	if ce := l.zap().WithOptions(zap.AddCallerSkip(1)).Check(level, text); ce != nil {
+		l.write(ce, fields)
+	}
+}
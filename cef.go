@@ -0,0 +1,200 @@
+package logger
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// SIEM encodings
+const (
+	// EncodingCEF writes ArcSight Common Event Format lines
+	EncodingCEF = "cef"
+	// EncodingLEEF writes IBM QRadar Log Event Extended Format 1.0 lines
+	EncodingLEEF = "leef"
+)
+
+// CEFConfig configures the CEF and LEEF encoders
+type CEFConfig struct {
+	// DeviceVendor, DeviceProduct and DeviceVersion fill the header
+	DeviceVendor  string
+	DeviceProduct string
+	DeviceVersion string
+	// SignatureField is the field holding the event class id, default "event",
+	// the message is used when the field is missing
+	SignatureField string
+	// Extensions maps field keys to extension keys, e.g. {"client_ip": "src"},
+	// unmapped fields are written with their own key
+	Extensions map[string]string
+	// OnlyMapped drops fields missing from Extensions
+	OnlyMapped bool
+	// CategoryField is the field holding the event category, default "category"
+	CategoryField string
+	// Categories selects the entries shipped by Socket, all when empty
+	Categories []string
+	// Format of the entries shipped by Socket, EncodingCEF (default) or EncodingLEEF
+	Format string
+	// Socket ships the selected categories to the SIEM when not nil,
+	// independently of Config.Encoding
+	Socket *SocketConfig
+}
+
+var cefSeverity = map[zapcore.Level]int{
+	zapcore.DebugLevel:  1,
+	zapcore.InfoLevel:   3,
+	zapcore.WarnLevel:   5,
+	zapcore.ErrorLevel:  7,
+	zapcore.DPanicLevel: 8,
+	zapcore.PanicLevel:  9,
+	zapcore.FatalLevel:  10,
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+	leefValueEscaper    = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+)
+
+type cefEncoder struct {
+	*mapEncoder
+	config CEFConfig
+	leef   bool
+}
+
+func newCEFEncoder(encCfg zapcore.EncoderConfig, config *CEFConfig, leef bool) *cefEncoder {
+	e := &cefEncoder{mapEncoder: newMapEncoder(encCfg, nil), leef: leef}
+	if config != nil {
+		e.config = *config
+	}
+	if e.config.SignatureField == "" {
+		e.config.SignatureField = "event"
+	}
+	return e
+}
+
+func (e *cefEncoder) Clone() zapcore.Encoder {
+	return &cefEncoder{mapEncoder: e.mapEncoder.Clone().(*mapEncoder), config: e.config, leef: e.leef}
+}
+
+func (e *cefEncoder) extensions(ent zapcore.Entry, fields []zapcore.Field) (string, [][2]string) {
+	values := make(map[string]interface{}, len(e.Fields)+len(fields))
+	for k, v := range e.Fields {
+		values[k] = v
+	}
+	for k, v := range fieldMap(fields) {
+		values[k] = v
+	}
+
+	signature := ent.Message
+	if v, ok := values[e.config.SignatureField]; ok {
+		signature = attributeString(v)
+		delete(values, e.config.SignatureField)
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	exts := make([][2]string, 0, len(keys))
+	for _, k := range keys {
+		key, ok := e.config.Extensions[k]
+		if !ok {
+			if e.config.OnlyMapped {
+				continue
+			}
+			key = k
+		}
+		exts = append(exts, [2]string{key, attributeString(values[k])})
+	}
+	return signature, exts
+}
+
+func (e *cefEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	signature, exts := e.extensions(ent, fields)
+	severity := cefSeverity[ent.Level]
+	if c, ok := lookupCustomLevel(ent.Level); ok {
+		severity = c.severity
+	}
+	millis := strconv.FormatInt(ent.Time.UnixNano()/1e6, 10)
+
+	buf := bufferPool.Get()
+	if e.leef {
+		buf.AppendString("LEEF:1.0|")
+		e.appendHeader(buf, signature)
+		buf.AppendString("devTime=" + millis)
+		buf.AppendString("\tsev=" + strconv.Itoa(severity))
+		buf.AppendString("\tmsg=" + leefValueEscaper.Replace(ent.Message))
+		for _, ext := range exts {
+			buf.AppendString("\t" + ext[0] + "=" + leefValueEscaper.Replace(ext[1]))
+		}
+	} else {
+		buf.AppendString("CEF:0|")
+		e.appendHeader(buf, signature)
+		buf.AppendString(cefHeaderEscaper.Replace(ent.Message))
+		buf.AppendString("|" + strconv.Itoa(severity) + "|")
+		buf.AppendString("rt=" + millis)
+		for _, ext := range exts {
+			buf.AppendString(" " + ext[0] + "=" + cefExtensionEscaper.Replace(ext[1]))
+		}
+	}
+	buf.AppendByte('\n')
+
+	return buf, nil
+}
+
+// appendHeader writes vendor|product|version|signature|
+func (e *cefEncoder) appendHeader(buf *buffer.Buffer, signature string) {
+	for _, s := range []string{e.config.DeviceVendor, e.config.DeviceProduct, e.config.DeviceVersion, signature} {
+		buf.AppendString(cefHeaderEscaper.Replace(s))
+		buf.AppendByte('|')
+	}
+}
+
+// cefWriter feeds the selected categories to the SIEM socket
+type cefWriter struct {
+	categories map[string]bool
+	field      string
+	out        *socketWriter
+}
+
+func newCEFWriter(config CEFConfig) (*cefWriter, error) {
+	out, err := newSocketWriter(*config.Socket)
+	if err != nil {
+		return nil, err
+	}
+	w := &cefWriter{field: config.CategoryField, out: out}
+	if w.field == "" {
+		w.field = "category"
+	}
+	if len(config.Categories) > 0 {
+		w.categories = make(map[string]bool, len(config.Categories))
+		for _, c := range config.Categories {
+			w.categories[c] = true
+		}
+	}
+	return w, nil
+}
+
+func (w *cefWriter) WriteEntry(ent zapcore.Entry, fields []zapcore.Field, encoded []byte) error {
+	if w.categories != nil {
+		category, _ := fieldMap(fields)[w.field].(string)
+		if !w.categories[category] {
+			return nil
+		}
+	}
+	_, err := w.out.Write(encoded)
+	return err
+}
+
+func (w *cefWriter) Sync() error {
+	return w.out.Sync()
+}
+
+func (w *cefWriter) Close() error {
+	return w.out.Close()
+}
@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Column sources understood by the sql based sinks, any other source is a field key
+const (
+	ColumnTimestamp = "@timestamp"
+	ColumnLevel     = "@level"
+	ColumnMessage   = "@message"
+	ColumnLogger    = "@logger"
+	ColumnCaller    = "@caller"
+	// ColumnFields holds every field encoded as a JSON object
+	ColumnFields = "@fields"
+)
+
+// ClickHouseConfig configures the ClickHouse sink
+//
+// DB must be opened with a ClickHouse database/sql driver, e.g.
+// sql.Open("clickhouse", dsn) after importing github.com/ClickHouse/clickhouse-go/v2
+type ClickHouseConfig struct {
+	DB *sql.DB
+	// Table to insert into
+	Table string
+	// Columns maps column names to sources, ColumnTimestamp, ColumnLevel,
+	// ColumnMessage, ColumnLogger, ColumnCaller, ColumnFields or a field key.
+	// Default is timestamp, level, message and fields columns
+	Columns map[string]string
+	// BatchSize is the number of rows per insert, default 1000
+	BatchSize int
+	// FlushInterval is the max time rows wait before an insert, default 1s
+	FlushInterval time.Duration
+	// AsyncInsert lets the server buffer inserts (async_insert=1) instead of
+	// writing a part per batch
+	AsyncInsert bool
+}
+
+type sqlRow []interface{}
+
+type clickHouseWriter struct {
+	config  ClickHouseConfig
+	columns []string
+	sources []string
+	query   string
+	batch   *batcher[sqlRow]
+}
+
+func newClickHouseWriter(config ClickHouseConfig) (*clickHouseWriter, error) {
+	if config.DB == nil || config.Table == "" {
+		return nil, errors.New("Bad clickhouse db or table")
+	}
+	if len(config.Columns) == 0 {
+		config.Columns = map[string]string{
+			"timestamp": ColumnTimestamp,
+			"level":     ColumnLevel,
+			"message":   ColumnMessage,
+			"fields":    ColumnFields,
+		}
+	}
+
+	w := &clickHouseWriter{config: config}
+	w.columns, w.sources = sortedColumns(config.Columns)
+	w.query = "INSERT INTO " + config.Table + " (" + strings.Join(w.columns, ", ") + ")"
+	if config.AsyncInsert {
+		w.query += " SETTINGS async_insert=1, wait_for_async_insert=0"
+	}
+	w.batch = newBatcher("clickhouse", config.BatchSize, config.FlushInterval, w.insert)
+
+	return w, nil
+}
+
+func sortedColumns(columns map[string]string) ([]string, []string) {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sources := make([]string, len(names))
+	for i, name := range names {
+		sources[i] = columns[name]
+	}
+	return names, sources
+}
+
+// sqlRowValues maps entry and fields to column values in sources order
+func sqlRowValues(sources []string, ent zapcore.Entry, fields []zapcore.Field) sqlRow {
+	values := fieldMap(fields)
+	row := make(sqlRow, len(sources))
+	for i, source := range sources {
+		switch source {
+		case ColumnTimestamp:
+			row[i] = ent.Time
+		case ColumnLevel:
+			row[i] = levelName(ent.Level)
+		case ColumnMessage:
+			row[i] = ent.Message
+		case ColumnLogger:
+			row[i] = ent.LoggerName
+		case ColumnCaller:
+			row[i] = ent.Caller.String()
+		case ColumnFields:
+			data, err := json.Marshal(values)
+			if err != nil {
+				data = []byte("{}")
+			}
+			row[i] = string(data)
+		default:
+			if v, ok := values[source]; ok {
+				row[i] = v
+			}
+		}
+	}
+	return row
+}
+
+func (w *clickHouseWriter) WriteEntry(ent zapcore.Entry, fields []zapcore.Field, encoded []byte) error {
+	return w.batch.Add(sqlRowValues(w.sources, ent, fields))
+}
+
+// insert sends one batch in a single transaction, which the ClickHouse
+// driver turns into one block insert
+func (w *clickHouseWriter) insert(rows []sqlRow) error {
+	tx, err := w.config.DB.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(w.query)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err := stmt.Exec(row...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (w *clickHouseWriter) Sync() error {
+	return w.batch.Flush()
+}
+
+func (w *clickHouseWriter) Close() error {
+	return w.batch.Close()
+}
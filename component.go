@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// componentResolver maps call sites to component names, cached per PC
+type componentResolver struct {
+	names map[string]string
+	cache sync.Map // uintptr -> string
+}
+
+func newComponentResolver(names map[string]string) *componentResolver {
+	return &componentResolver{names: names}
+}
+
+// packagePath extracts the package from a function name such as
+// github.com/acme/svc/db.(*Pool).Get
+func packagePath(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[slash+1:], '.'); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
+
+func (r *componentResolver) component(pc uintptr) string {
+	if v, ok := r.cache.Load(pc); ok {
+		return v.(string)
+	}
+
+	name := ""
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		pkg := packagePath(fn.Name())
+		name = pkg[strings.LastIndexByte(pkg, '/')+1:]
+		match := ""
+		for prefix, rename := range r.names {
+			if (pkg == prefix || strings.HasPrefix(pkg, prefix+"/")) && len(prefix) > len(match) {
+				match, name = prefix, rename
+			}
+		}
+	}
+
+	r.cache.Store(pc, name)
+	return name
+}
+
+func (r *componentResolver) process(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+	if !ent.Caller.Defined {
+		return ent, fields, true
+	}
+	if name := r.component(ent.Caller.PC); name != "" {
+		fields = append(fields, String("component", name))
+	}
+	return ent, fields, true
+}
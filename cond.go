@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var nopZapLogger = zap.NewNop()
+
+// zap returns the zap logger entries of l go to, the fields of Ctx are
+// added by write once an entry passed Check, cloning the core for them
+// would cost every entry, the disabled ones too
+func (l *Log) zap() *zap.Logger {
+	if l.muted {
+		return nopZapLogger
+	}
+	var z *zap.Logger
+	if l.name != "" {
+		z = namedZapFor(l.name)
+	} else {
+		z = currentZap()
+	}
+	return z
+}
+
+// write writes ce with a pooled copy of fields, fields itself does not
+// escape so the variadic slice of the caller stays on its stack
+func (l *Log) write(ce *zapcore.CheckedEntry, fields []zapcore.Field) {
+	l.writePooled(ce, getFields(fields, 0))
+}
+
+// writePooled adds the fields of Ctx to the pooled p, writes ce and
+// releases p
+func (l *Log) writePooled(ce *zapcore.CheckedEntry, p *[]zapcore.Field) {
+	if l.ctx != nil {
+		*p = append(*p, contextFields(l.ctx)...)
+	}
+	ce.Write(*p...)
+	putFields(p)
+}
+
+// IfError logs msg at the error level with err attached, nothing is logged
+// when err is nil:
+//
+//	log.IfError(f.Close(), "Close file failed", logger.String("file", name))
+func (l *Log) IfError(err error, msg string, fields ...zapcore.Field) {
+	if err == nil {
+		return
+	}
+	if ce := l.zap().Check(zapcore.ErrorLevel, msg); ce != nil {
+		p := getFields(fields, 1)
+		*p = append(*p, Err(err))
+		l.writePooled(ce, p)
+	}
+}
+
+// When returns a logger that only logs when cond is true:
+//
+//	log.When(retries > 3).Warn("Still retrying", logger.Int("retries", retries))
+func (l *Log) When(cond bool) *Log {
+	if cond {
+		return l
+	}
+	muted := *l
+	muted.muted = true
+	return &muted
+}
@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCtxFieldsOnlyWhenWritten(t *testing.T) {
+	old := state.Load()
+	obs, logs := observer.New(zapcore.InfoLevel)
+	state.Store(&loggerState{zap: zap.New(obs), config: old.config})
+	t.Cleanup(func() { state.Store(old) })
+
+	log := &Log{}
+	ctx := WithCorrelationID(context.Background(), "req-1")
+	// the Log copy of Ctx at most, the core is not cloned any more
+	if allocs := testing.AllocsPerRun(100, func() { log.Ctx(ctx).Debug("Disabled") }); !raceEnabled && allocs > 1 {
+		t.Errorf("disabled Ctx entry: %v allocs, want at most 1", allocs)
+	}
+
+	log.Ctx(ctx).Info("Enabled", String("key", "value"))
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("%d entries logged, want 1", len(entries))
+	}
+	if got := entries[0].ContextMap()[CorrelationField]; got != "req-1" {
+		t.Errorf("correlation ID %v, want req-1", got)
+	}
+}
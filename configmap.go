@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnnotationPrefix starts the pod annotations read by WatchAnnotations:
+//
+//	logger.gwtony.github.io/level: debug
+//	logger.gwtony.github.io/named-levels: svc.db=debug,svc.http=warn
+//	logger.gwtony.github.io/sampling: '{"first": 100, "thereafter": 10}'
+//
+// The annotations reach the pod through a downward API volume:
+//
+//	volumes:
+//	- name: podinfo
+//	  downwardAPI:
+//	    items:
+//	    - path: annotations
+//	      fieldRef:
+//	        fieldPath: metadata.annotations
+//
+// so kubectl annotate changes the level of a running pod.
+const AnnotationPrefix = "logger.gwtony.github.io/"
+
+// defaultWatchInterval is the poll interval of the file watchers
+const defaultWatchInterval = 2 * time.Second
+
+// WatchFile applies the RemoteSettings stored in path, typically a key of
+// a mounted ConfigMap, and every change to it until Close. The file is read
+// again every interval (2s when 0) by path, so the atomic swap of the
+// ..data symlink done by the kubelet is picked up like any other write.
+func WatchFile(path string, interval time.Duration) *RemoteController {
+	return watchFile(newRemoteController("file", path), interval, func(data []byte) ([]byte, error) {
+		return data, nil
+	})
+}
+
+// WatchAnnotations applies the AnnotationPrefix annotations of the
+// downward API file at path and every change to them until Close
+func WatchAnnotations(path string, interval time.Duration) *RemoteController {
+	return watchFile(newRemoteController("annotations", path), interval, annotationSettings)
+}
+
+// watchFile polls c.key, convert turns the file into a RemoteSettings document
+func watchFile(c *RemoteController, interval time.Duration, convert func([]byte) ([]byte, error)) *RemoteController {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	go func() {
+		defer close(c.done)
+		missing := false
+		for {
+			data, err := os.ReadFile(c.key)
+			switch {
+			case os.IsNotExist(err):
+				if !missing {
+					c.deleted()
+				}
+				missing = true
+			case err != nil:
+				c.failed("Failed read logging config file", err)
+			default:
+				missing = false
+				if data, err = convert(data); err != nil {
+					c.failed("Bad logging annotations, keeping last known good", err)
+				} else {
+					c.apply(data)
+				}
+			}
+			if !c.wait(interval) {
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// annotationSettings converts the downward API annotations file, one
+// key="quoted value" per line, to a RemoteSettings document
+func annotationSettings(data []byte) ([]byte, error) {
+	var settings RemoteSettings
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, quoted, ok := strings.Cut(scanner.Text(), "=")
+		if !ok || !strings.HasPrefix(key, AnnotationPrefix) {
+			continue
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, err
+		}
+
+		switch strings.TrimPrefix(key, AnnotationPrefix) {
+		case "level":
+			settings.Level = value
+		case "named-levels":
+			settings.NamedLevels = make(map[string]string)
+			for _, pair := range strings.Split(value, ",") {
+				if name, level, ok := strings.Cut(strings.TrimSpace(pair), "="); ok {
+					settings.NamedLevels[name] = level
+				}
+			}
+		case "sampling":
+			settings.Sampling = new(RemoteSampling)
+			if err := json.Unmarshal([]byte(value), settings.Sampling); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(settings)
+}
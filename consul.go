@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+// consulWaitTime bounds every blocking query
+const consulWaitTime = 5 * time.Minute
+
+// WatchConsul applies the RemoteSettings stored under key in the Consul KV
+// store and every change to it, until Close
+func WatchConsul(client *consul.Client, key string) *RemoteController {
+	c := newRemoteController("consul", key)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-c.stop
+		cancel()
+	}()
+	go func() {
+		defer close(c.done)
+		var index uint64
+		for {
+			opts := (&consul.QueryOptions{WaitIndex: index, WaitTime: consulWaitTime}).WithContext(ctx)
+			pair, meta, err := client.KV().Get(key, opts)
+			if err != nil {
+				if ctx.Err() == nil {
+					c.failed("Failed watch remote logging config", err)
+				}
+				if !c.wait(remoteRetryInterval) {
+					return
+				}
+				continue
+			}
+			if meta.LastIndex == index {
+				// the blocking query timed out
+				continue
+			}
+			// the index going backwards means the store was reset
+			if meta.LastIndex < index {
+				index = 0
+			} else {
+				index = meta.LastIndex
+			}
+			if pair == nil {
+				c.deleted()
+			} else {
+				c.apply(pair.Value)
+			}
+		}
+	}()
+	return c
+}
@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// Correlation ID formats
+const (
+	// CorrelationUUIDv7 is a time ordered RFC 9562 UUID, the default
+	CorrelationUUIDv7 = "uuidv7"
+	// CorrelationULID is a 26 characters Crockford base32 ULID
+	CorrelationULID = "ulid"
+	// CorrelationKSUID is a 27 characters base62 KSUID
+	CorrelationKSUID = "ksuid"
+)
+
+// CorrelationHeader is the header read and set by CorrelationMiddleware
+const CorrelationHeader = "X-Correlation-ID"
+
+// CorrelationField is the key of the correlation ID of the entries logged
+// with Log.Ctx
+const CorrelationField = "correlation_id"
+
+type correlationKey struct{}
+
+// NewCorrelationID returns a new ID in the format of Config.CorrelationIDFormat
+func NewCorrelationID() string {
+	return newCorrelationID(currentConfig().CorrelationIDFormat, time.Now())
+}
+
+func newCorrelationID(format string, now time.Time) string {
+	switch format {
+	case CorrelationULID:
+		return newULID(now)
+	case CorrelationKSUID:
+		return newKSUID(now)
+	}
+	return newUUIDv7(now)
+}
+
+func newUUIDv7(now time.Time) string {
+	var b [16]byte
+	rand.Read(b[6:])
+	ms := uint64(now.UnixMilli())
+	b[0], b[1], b[2], b[3], b[4], b[5] = byte(ms>>40), byte(ms>>32), byte(ms>>24), byte(ms>>16), byte(ms>>8), byte(ms)
+	b[6] = b[6]&0x0f | 0x70
+	b[8] = b[8]&0x3f | 0x80
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	hex.Encode(s[9:13], b[4:6])
+	hex.Encode(s[14:18], b[6:8])
+	hex.Encode(s[19:23], b[8:10])
+	hex.Encode(s[24:], b[10:])
+	s[8], s[13], s[18], s[23] = '-', '-', '-', '-'
+	return string(s[:])
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+func newULID(now time.Time) string {
+	var b [16]byte
+	ms := uint64(now.UnixMilli())
+	binary.BigEndian.PutUint16(b[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:6], uint32(ms))
+	rand.Read(b[6:])
+
+	// 128 bits as 26 base32 digits, the first one holds 3 bits
+	hi := binary.BigEndian.Uint64(b[0:8])
+	lo := binary.BigEndian.Uint64(b[8:16])
+	var s [26]byte
+	for i := 25; i >= 0; i-- {
+		s[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
+
+// ksuidEpoch is the KSUID time origin, 2014-05-13
+const ksuidEpoch = 1400000000
+
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+func newKSUID(now time.Time) string {
+	var b [20]byte
+	binary.BigEndian.PutUint32(b[0:4], uint32(now.Unix()-ksuidEpoch))
+	rand.Read(b[4:])
+
+	n := new(big.Int).SetBytes(b[:])
+	s := []byte("000000000000000000000000000")
+	base, mod := big.NewInt(62), new(big.Int)
+	for i := len(s) - 1; n.Sign() > 0; i-- {
+		n.DivMod(n, base, mod)
+		s[i] = base62[mod.Int64()]
+	}
+	return string(s)
+}
+
+// WithCorrelationID returns a context carrying id, the entries logged
+// with Log.Ctx(ctx) get it as CorrelationField
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns the ID carried by ctx, empty when there is none
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// CorrelationMiddleware reuses the CorrelationHeader of the request or
+// sets a new ID, the ID is echoed in the response and carried by the
+// request context:
+//
+//	http.ListenAndServe(addr, logger.CorrelationMiddleware(mux))
+func CorrelationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(CorrelationHeader)
+		if id == "" || len(id) > 128 {
+			id = NewCorrelationID()
+		}
+		w.Header().Set(CorrelationHeader, id)
+		next.ServeHTTP(w, r.WithContext(WithCorrelationID(r.Context(), id)))
+	})
+}
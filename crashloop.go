@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// CrashLoopConfig enables crash loop detection, every Fatal is recorded in
+// StateFile and a process starting after Threshold fatals within Window is
+// considered crash looping: it logs at the debug level and its fatal entry
+// carries extended diagnostics
+type CrashLoopConfig struct {
+	// StateFile keeps the recent fatal times across restarts
+	StateFile string
+	// Threshold is the number of fatals within Window, default 3
+	Threshold int
+	// Window default 10m
+	Window time.Duration
+}
+
+type crashState struct {
+	Fatals []time.Time `json:"fatals"`
+}
+
+var (
+	crashLoopConfig  atomic.Pointer[CrashLoopConfig]
+	crashLoopRunning atomic.Bool
+)
+
+func (c CrashLoopConfig) withDefaults() CrashLoopConfig {
+	if c.Threshold <= 0 {
+		c.Threshold = 3
+	}
+	if c.Window <= 0 {
+		c.Window = 10 * time.Minute
+	}
+	return c
+}
+
+func readCrashState(path string) crashState {
+	var state crashState
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+// recent keeps the fatals inside window, at most limit of them so the
+// state file stays small however long the loop runs
+func (s crashState) recent(window time.Duration, limit int) []time.Time {
+	cutoff := time.Now().Add(-window)
+	var recent []time.Time
+	for _, t := range s.Fatals {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) > limit {
+		recent = recent[len(recent)-limit:]
+	}
+	return recent
+}
+
+// detectCrashLoop loads the state file and reports whether the previous
+// runs of the process are crash looping
+func detectCrashLoop(config CrashLoopConfig) bool {
+	config = config.withDefaults()
+	crashLoopConfig.Store(&config)
+
+	looping := len(readCrashState(config.StateFile).recent(config.Window, config.Threshold)) >= config.Threshold
+	crashLoopRunning.Store(looping)
+	return looping
+}
+
+// recordFatal appends now to the state file and returns the diagnostics
+// to attach to the fatal entry
+func recordFatal() []zapcore.Field {
+	config := crashLoopConfig.Load()
+	if config == nil {
+		return nil
+	}
+
+	state := readCrashState(config.StateFile)
+	state.Fatals = append(state.recent(config.Window, config.Threshold*2), time.Now())
+	if data, err := json.Marshal(state); err == nil {
+		os.WriteFile(config.StateFile, data, 0644)
+	}
+
+	if !crashLoopRunning.Load() {
+		return nil
+	}
+	return crashDiagnostics(len(state.Fatals))
+}
+
+func crashDiagnostics(fatals int) []zapcore.Field {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	stacks := make([]byte, 64<<10)
+	stacks = stacks[:runtime.Stack(stacks, true)]
+
+	fields := []zapcore.Field{
+		Bool("crash_loop", true),
+		Int("recent_fatals", fatals),
+		Duration("uptime", time.Since(processStart)),
+		Int("goroutines", runtime.NumGoroutine()),
+		Int64("heap_alloc", int64(m.HeapAlloc)),
+		Int64("gc_count", int64(m.NumGC)),
+		Strings("args", os.Args),
+		String("goroutine_stacks", string(stacks)),
+	}
+	if wd, err := os.Getwd(); err == nil {
+		fields = append(fields, String("cwd", wd))
+	}
+	return append(fields, hostFields()...)
+}
+
+// crashLoopLevel lowers level to debug while crash looping
+func crashLoopLevel(config *CrashLoopConfig, level zapcore.Level) zapcore.Level {
+	if config == nil || config.StateFile == "" || !detectCrashLoop(*config) {
+		return level
+	}
+	return zap.DebugLevel
+}
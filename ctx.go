@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// contextKey is the key of the field carrying the context of Log.Ctx, the
+// field is skipped by the encoders
+const contextKey = "context"
+
+// Keys of the fields of Meta
+const (
+	MethodField   = "http_method"
+	PathField     = "http_path"
+	UserIDField   = "user_id"
+	TenantIDField = "tenant_id"
+)
+
+// Meta is the request metadata logged by Log.Ctx, the empty members are
+// not logged
+type Meta struct {
+	Method   string
+	Path     string
+	UserID   string
+	TenantID string
+}
+
+type metaKey struct{}
+
+// WithRequestMeta returns a copy of ctx carrying meta, the members left
+// empty keep the value set by an earlier call, so a handler can add the
+// user once authenticated:
+//
+//	ctx = logger.WithRequestMeta(r.Context(), logger.Meta{Method: r.Method, Path: r.URL.Path})
+//	...
+//	ctx = logger.WithRequestMeta(ctx, logger.Meta{UserID: user.ID})
+func WithRequestMeta(ctx context.Context, meta Meta) context.Context {
+	if prev, ok := RequestMeta(ctx); ok {
+		if meta.Method == "" {
+			meta.Method = prev.Method
+		}
+		if meta.Path == "" {
+			meta.Path = prev.Path
+		}
+		if meta.UserID == "" {
+			meta.UserID = prev.UserID
+		}
+		if meta.TenantID == "" {
+			meta.TenantID = prev.TenantID
+		}
+	}
+	return context.WithValue(ctx, metaKey{}, meta)
+}
+
+// RequestMeta returns the metadata carried by ctx
+func RequestMeta(ctx context.Context) (Meta, bool) {
+	meta, ok := ctx.Value(metaKey{}).(Meta)
+	return meta, ok
+}
+
+// fields returns the fields of the members set
+func (m Meta) fields() []zapcore.Field {
+	var fields []zapcore.Field
+	for _, f := range []struct{ key, value string }{
+		{MethodField, m.Method},
+		{PathField, m.Path},
+		{UserIDField, m.UserID},
+		{TenantIDField, m.TenantID},
+	} {
+		if f.value != "" {
+			fields = append(fields, String(f.key, f.value))
+		}
+	}
+	return fields
+}
+
+// Ctx returns a logger whose entries carry ctx, the entry processors read
+// the active span and baggage from it:
+//
+//	log.Ctx(r.Context()).Warn("Slow query", logger.Duration("elapsed", d))
+func (l *Log) Ctx(ctx context.Context) *Log {
+	c := *l
+	c.ctx = ctx
+	return &c
+}
+
+// contextFields are the fields added by Log.Ctx, ctx itself, its
+// correlation ID, worker ID, goroutine ID and request metadata
+func contextFields(ctx context.Context) []zapcore.Field {
+	fields := []zapcore.Field{{Key: contextKey, Type: zapcore.SkipType, Interface: ctx}}
+	if id := CorrelationID(ctx); id != "" {
+		fields = append(fields, String(CorrelationField, id))
+	}
+	if id := WorkerID(ctx); id != "" {
+		fields = append(fields, String(WorkerIDField, id))
+	}
+	if id, ok := ctxGoroutineID(ctx); ok {
+		fields = append(fields, zapcore.Field{Key: GoroutineIDField, Type: zapcore.Uint64Type, Integer: int64(id)})
+	}
+	if meta, ok := RequestMeta(ctx); ok {
+		fields = append(fields, meta.fields()...)
+	}
+	return fields
+}
+
+// entryContext finds the context of Log.Ctx among the fields of an entry
+// and the fields added by With, nil when there is none
+func entryContext(with, fields []zapcore.Field) context.Context {
+	for _, list := range [][]zapcore.Field{fields, with} {
+		for i := range list {
+			if list[i].Type != zapcore.SkipType || list[i].Key != contextKey {
+				continue
+			}
+			if ctx, ok := list[i].Interface.(context.Context); ok {
+				return ctx
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Extra named levels, they sort between the standard ones: NoticeLevel
+// between info and warn, CriticalLevel between error and dpanic
+const (
+	NoticeLevel   zapcore.Level = 16
+	CriticalLevel zapcore.Level = 17
+)
+
+// customLevel is a level outside the zap set, rank orders it among the
+// standard levels whose rank is 10 times their value
+type customLevel struct {
+	name     string
+	rank     int
+	severity int
+}
+
+var (
+	customLevelsMu sync.RWMutex
+	customLevels   = map[zapcore.Level]customLevel{
+		NoticeLevel:   {name: "notice", rank: int(zapcore.InfoLevel)*10 + 5, severity: 4},
+		CriticalLevel: {name: "critical", rank: int(zapcore.ErrorLevel)*10 + 5, severity: 8},
+	}
+)
+
+func standardLevel(level zapcore.Level) bool {
+	return level >= zapcore.DebugLevel && level <= zapcore.FatalLevel
+}
+
+// RegisterLevel defines an extra level named name sorting right above the
+// standard level above, level must be a free value outside -1..5. Entries
+// are logged with Log.Log and name is accepted by SetLogLevel.
+func RegisterLevel(name string, level, above zapcore.Level) error {
+	if name == "" || standardLevel(level) || !standardLevel(above) || above == zapcore.FatalLevel {
+		return errors.New("Bad custom level")
+	}
+
+	customLevelsMu.Lock()
+	defer customLevelsMu.Unlock()
+
+	if _, ok := customLevels[level]; ok {
+		return errors.New("Custom level already defined")
+	}
+	rank := int(above)*10 + 1
+	for _, c := range customLevels {
+		if c.name == name {
+			return errors.New("Custom level already defined")
+		}
+		if c.rank >= rank && c.rank < int(above+1)*10 {
+			rank = c.rank + 1
+		}
+	}
+	if rank >= int(above+1)*10 {
+		return errors.New("Too many custom levels")
+	}
+	customLevels[level] = customLevel{name: name, rank: rank, severity: int(above)*2 + 4}
+	return nil
+}
+
+func lookupCustomLevel(level zapcore.Level) (customLevel, bool) {
+	customLevelsMu.RLock()
+	c, ok := customLevels[level]
+	customLevelsMu.RUnlock()
+	return c, ok
+}
+
+func levelRank(level zapcore.Level) int {
+	if c, ok := lookupCustomLevel(level); ok {
+		return c.rank
+	}
+	return int(level) * 10
+}
+
+// levelAtLeast compares levels by rank so extra levels sort correctly
+func levelAtLeast(level, min zapcore.Level) bool {
+	return levelRank(level) >= levelRank(min)
+}
+
+// levelName is the lowercase name of standard and extra levels
+func levelName(level zapcore.Level) string {
+	if c, ok := lookupCustomLevel(level); ok {
+		return c.name
+	}
+	return level.String()
+}
+
+// parseLevel resolves standard and extra level names
+func parseLevel(name string) (zapcore.Level, error) {
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(name)); err == nil {
+		return level, nil
+	}
+
+	customLevelsMu.RLock()
+	defer customLevelsMu.RUnlock()
+	for level, c := range customLevels {
+		if strings.EqualFold(c.name, name) {
+			return level, nil
+		}
+	}
+	return 0, errors.New("Bad log level")
+}
+
+// newLevelEnabler enables min and everything ranking above it
+func newLevelEnabler(min zapcore.Level) zapcore.LevelEnabler {
+	return zap.LevelEnablerFunc(func(level zapcore.Level) bool {
+		return levelAtLeast(level, min)
+	})
+}
+
+// Log logs a message at any standard or extra level
+func (l *Log) Log(level zapcore.Level, msg string, fields ...zapcore.Field) {
+	if ce := l.zap().Check(level, msg); ce != nil {
+		l.write(ce, fields)
+	}
+}
+
+// Notice logs a message at the notice level, between info and warn
+func (l *Log) Notice(msg string, fields ...zapcore.Field) {
+	if ce := l.zap().Check(NoticeLevel, msg); ce != nil {
+		l.write(ce, fields)
+	}
+}
+
+// Critical logs a message at the critical level, between error and dpanic
+func (l *Log) Critical(msg string, fields ...zapcore.Field) {
+	if ce := l.zap().Check(CriticalLevel, msg); ce != nil {
+		l.write(ce, fields)
+	}
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"encoding/binary"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+)
+
+// DatadogConfig is the Datadog preset, the entries get the attributes the
+// Datadog log pipeline reserves: the level is written as "status" with
+// the Datadog names, service, env and version are added to every entry
+// and the entries logged with Log.Ctx inside a span get dd.trace_id and
+// dd.span_id in the decimal form Datadog correlates with APM traces.
+type DatadogConfig struct {
+	// Service, Env and Version default to the DD_SERVICE, DD_ENV and
+	// DD_VERSION variables of unified service tagging
+	Service string
+	Env     string
+	Version string
+}
+
+func (c DatadogConfig) withDefaults() DatadogConfig {
+	if c.Service == "" {
+		c.Service = os.Getenv("DD_SERVICE")
+	}
+	if c.Env == "" {
+		c.Env = os.Getenv("DD_ENV")
+	}
+	if c.Version == "" {
+		c.Version = os.Getenv("DD_VERSION")
+	}
+	return c
+}
+
+// fields are the unified service tags set
+func (c DatadogConfig) fields() []zapcore.Field {
+	var fields []zapcore.Field
+	for _, f := range []struct{ key, value string }{
+		{"service", c.Service},
+		{"env", c.Env},
+		{"version", c.Version},
+	} {
+		if f.value != "" {
+			fields = append(fields, String(f.key, f.value))
+		}
+	}
+	return fields
+}
+
+// datadogStatus names the levels Datadog does not know
+var datadogStatus = map[zapcore.Level]string{
+	zapcore.DPanicLevel: "critical",
+	zapcore.PanicLevel:  "alert",
+	zapcore.FatalLevel:  "emergency",
+}
+
+// datadogTraceID is the lower 64 bits of the trace ID in decimal, the
+// trace ID of the Datadog tracers
+func datadogTraceID(id trace.TraceID) string {
+	return strconv.FormatUint(binary.BigEndian.Uint64(id[8:]), 10)
+}
+
+func datadogSpanID(id trace.SpanID) string {
+	return strconv.FormatUint(binary.BigEndian.Uint64(id[:]), 10)
+}
+
+// datadogTraceProcess adds dd.trace_id and dd.span_id
+func datadogTraceProcess(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+	ctx := entryContext(context, fields)
+	if ctx == nil {
+		return ent, fields, true
+	}
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ent, fields, true
+	}
+	return ent, append(fields,
+		String("dd.trace_id", datadogTraceID(sc.TraceID())),
+		String("dd.span_id", datadogSpanID(sc.SpanID())),
+	), true
+}
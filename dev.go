@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// EncodingDev prints the message on one line and the fields indented
+// beneath it with aligned keys, for humans reading a terminal
+const EncodingDev = "dev"
+
+const (
+	colorReset   = "\x1b[0m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+	colorGray    = "\x1b[90m"
+	colorBold    = "\x1b[1m"
+)
+
+var devLevelColors = map[zapcore.Level]string{
+	zapcore.DebugLevel:  colorMagenta,
+	zapcore.InfoLevel:   colorGreen,
+	zapcore.WarnLevel:   colorYellow,
+	zapcore.ErrorLevel:  colorRed,
+	zapcore.DPanicLevel: colorRed,
+	zapcore.PanicLevel:  colorRed,
+	zapcore.FatalLevel:  colorRed,
+}
+
+// prettyEnabled reports whether LOG_PRETTY=1 asks for the dev encoding
+func prettyEnabled() bool {
+	return os.Getenv("LOG_PRETTY") == "1"
+}
+
+type devEncoder struct {
+	*mapEncoder
+	config Config
+	color  bool
+}
+
+func newDevEncoder(encCfg zapcore.EncoderConfig, config Config, color bool) *devEncoder {
+	return &devEncoder{mapEncoder: newMapEncoder(encCfg, nil), config: config, color: color}
+}
+
+func (e *devEncoder) Clone() zapcore.Encoder {
+	return &devEncoder{mapEncoder: e.mapEncoder.Clone().(*mapEncoder), config: e.config, color: e.color}
+}
+
+func (e *devEncoder) levelColor(level zapcore.Level) string {
+	if code := colorCode(e.config.LevelColors[level]); code != "" {
+		return code
+	}
+	if color, ok := devLevelColors[level]; ok {
+		return color
+	}
+	if levelAtLeast(level, zapcore.ErrorLevel) {
+		return colorRed
+	}
+	return colorCyan
+}
+
+func (e *devEncoder) levelLabel(level zapcore.Level) string {
+	if label, ok := e.config.LevelLabels[level]; ok {
+		return label
+	}
+	return strings.ToUpper(levelName(level))
+}
+
+func (e *devEncoder) paint(buf *buffer.Buffer, color, s string) {
+	if e.color && color != "" {
+		buf.AppendString(color)
+		buf.AppendString(s)
+		buf.AppendString(colorReset)
+		return
+	}
+	buf.AppendString(s)
+}
+
+func (e *devEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	values := make(map[string]interface{}, len(e.Fields)+len(fields))
+	for k, v := range e.Fields {
+		values[k] = v
+	}
+	for k, v := range fieldMap(fields) {
+		values[k] = v
+	}
+	keys := make([]string, 0, len(values))
+	width := 0
+	for k := range values {
+		keys = append(keys, k)
+		if len(k) > width {
+			width = len(k)
+		}
+	}
+	sort.Strings(keys)
+
+	buf := bufferPool.Get()
+	e.paint(buf, colorGray, ent.Time.Format("15:04:05.000"))
+	buf.AppendByte(' ')
+	e.paint(buf, e.levelColor(ent.Level), e.levelLabel(ent.Level))
+	buf.AppendByte(' ')
+	if ent.LoggerName != "" {
+		e.paint(buf, colorBlue, "["+ent.LoggerName+"] ")
+	}
+	e.paint(buf, colorBold, ent.Message)
+	if ent.Caller.Defined {
+		buf.AppendByte(' ')
+		e.paint(buf, colorGray, ent.Caller.TrimmedPath())
+	}
+	buf.AppendByte('\n')
+
+	for _, k := range keys {
+		buf.AppendString("    ")
+		e.paint(buf, colorCyan, k)
+		buf.AppendString(strings.Repeat(" ", width-len(k)))
+		buf.AppendString(" = ")
+		buf.AppendString(attributeString(values[k]))
+		buf.AppendByte('\n')
+	}
+	if ent.Stack != "" {
+		for _, line := range strings.Split(ent.Stack, "\n") {
+			buf.AppendString("    ")
+			e.paint(buf, colorGray, line)
+			buf.AppendByte('\n')
+		}
+	}
+
+	return buf, nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package logger
+
+import (
+	"errors"
+)
+
+func diskFree(dir string) (int64, error) {
+	return 0, errors.New("Disk guard is only supported on linux and darwin")
+}
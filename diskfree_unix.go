@@ -0,0 +1,16 @@
+//go:build linux || darwin
+
+package logger
+
+import (
+	"syscall"
+)
+
+// diskFree is the space available to unprivileged users under dir
+func diskFree(dir string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return int64(uint64(st.Bavail) * uint64(st.Bsize)), nil
+}
@@ -0,0 +1,221 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// DiskGuardConfig watches the free space of the log directory. Below
+// MinFree the logger degrades: entries under Level are dropped from every
+// output and a warning goes to the Fallback sink, the logfile being the
+// likely victim. It recovers once the free space is back above MinFree.
+type DiskGuardConfig struct {
+	// MinFree is the free space in bytes below which the logger degrades,
+	// default 512MB
+	MinFree int64
+	// Interval between checks, default 10s
+	Interval time.Duration
+	// Level is the lowest level kept while degraded, warn when nil
+	Level *zapcore.Level
+	// Fallback is the sink of the warning, JSON on stderr when nil
+	Fallback *SinkConfig
+	// Reclaim rotates the logfile early and gzips the rotated files when
+	// degrading
+	Reclaim bool
+}
+
+func (c DiskGuardConfig) withDefaults() DiskGuardConfig {
+	if c.MinFree <= 0 {
+		c.MinFree = 512 << 20
+	}
+	if c.Interval <= 0 {
+		c.Interval = 10 * time.Second
+	}
+	if c.Level == nil {
+		level := zapcore.WarnLevel
+		c.Level = &level
+	}
+	if c.Fallback == nil {
+		c.Fallback = &SinkConfig{Type: SinkConsole, Stderr: true, Encoding: EncodingJSON}
+	}
+	return c
+}
+
+var diskGuard = struct {
+	mu   sync.Mutex
+	stop chan struct{}
+	// degraded is set while the free space is low, level is then the
+	// lowest level kept
+	degraded atomic.Bool
+	level    atomic.Int32
+}{}
+
+// DiskDegraded reports whether the disk guard drops entries for lack of space
+func DiskDegraded() bool {
+	return diskGuard.degraded.Load()
+}
+
+// diskGuardProcess drops the entries below the guard level while degraded
+func diskGuardProcess(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+	if diskGuard.degraded.Load() && !levelAtLeast(ent.Level, zapcore.Level(diskGuard.level.Load())) {
+		return ent, fields, false
+	}
+	return ent, fields, true
+}
+
+// startDiskGuard replaces the running guard, file is the logfile writer
+func startDiskGuard(config Config, file zapcore.WriteSyncer) {
+	diskGuard.mu.Lock()
+	defer diskGuard.mu.Unlock()
+
+	if diskGuard.stop != nil {
+		close(diskGuard.stop)
+		diskGuard.stop = nil
+	}
+	diskGuard.degraded.Store(false)
+	if config.DiskGuard == nil || !config.FileLoggingEnabled {
+		return
+	}
+
+	guard := config.DiskGuard.withDefaults()
+	diskGuard.level.Store(int32(*guard.Level))
+	fallback, closer, err := newSinkCore(config, *guard.Fallback)
+	if err != nil {
+		internalLog(zapcore.ErrorLevel, "Failed create disk guard fallback sink", String("sink", guard.Fallback.name()), Err(err))
+		return
+	}
+	dir := config.Directory
+	if dir == "" {
+		dir = "."
+	}
+
+	stop := make(chan struct{})
+	diskGuard.stop = stop
+	go func() {
+		ticker := time.NewTicker(guard.Interval)
+		defer ticker.Stop()
+		if closer != nil {
+			defer closer.Close()
+		}
+
+		for {
+			select {
+			case <-ticker.C:
+				checkDisk(guard, dir, config.Filename, file, fallback)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func checkDisk(guard DiskGuardConfig, dir, filename string, file zapcore.WriteSyncer, fallback zapcore.Core) {
+	free, err := diskFree(dir)
+	if err != nil {
+		internalLog(zapcore.WarnLevel, "Failed check log directory space", String("directory", dir), Err(err))
+		return
+	}
+
+	low := free < guard.MinFree
+	if low == diskGuard.degraded.Load() {
+		return
+	}
+	diskGuard.degraded.Store(low)
+	ent := zapcore.Entry{Time: time.Now(), LoggerName: InternalLoggerName}
+	fields := []zapcore.Field{String("directory", dir), Int64("free_bytes", free), Int64("min_free_bytes", guard.MinFree)}
+	if !low {
+		ent.Level, ent.Message = zapcore.InfoLevel, "Log directory space recovered"
+		fallback.Write(ent, fields)
+		return
+	}
+
+	ent.Level, ent.Message = zapcore.WarnLevel, "Log directory low on space, dropping entries below "+levelName(*guard.Level)
+	if guard.Reclaim {
+		fields = append(fields, Int64("reclaimed_bytes", reclaimDisk(dir, filename, file)))
+	}
+	fallback.Write(ent, fields)
+	fallback.Sync()
+}
+
+// reclaimDisk rotates the logfile and gzips the rotated files, returning
+// the bytes saved
+func reclaimDisk(dir, filename string, file zapcore.WriteSyncer) int64 {
+	if r, ok := file.(interface{ Rotate() error }); ok {
+		if err := r.Rotate(); err != nil {
+			internalLog(zapcore.WarnLevel, "Failed rotate log file", String("file", filename), Err(err))
+		}
+	}
+
+	ext := filepath.Ext(filename)
+	prefix := strings.TrimSuffix(filename, ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	var saved int64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		n, err := gzipFile(filepath.Join(dir, name))
+		if err != nil {
+			internalLog(zapcore.WarnLevel, "Failed compress rotated log file", String("file", name), Err(err))
+			continue
+		}
+		saved += n
+	}
+	return saved
+}
+
+// gzipFile replaces path by path.gz, returning the bytes saved, the
+// compressed file shows up complete
+func gzipFile(path string) (int64, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	tmp := path + ".gz.tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return 0, err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+
+	gz, err := os.Stat(path + ".gz")
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Remove(path); err != nil {
+		return 0, err
+	}
+	return info.Size() - gz.Size(), nil
+}
@@ -0,0 +1,202 @@
+package logger
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DualWriteConfig also sends every entry to the logrus logger of the
+// pipeline being replaced, and compares what both pipelines wrote, to check
+// parity before the cutover. Panic and fatal entries are logged by logrus
+// at its fatal level without exiting nor panicking.
+type DualWriteConfig struct {
+	// Logrus is the old pipeline
+	Logrus *logrus.Logger
+	// ReportInterval logs the comparison at this interval, through the
+	// internal logger, default 1 minute
+	ReportInterval time.Duration
+}
+
+// DualWriteReport compares the pipelines since Since
+type DualWriteReport struct {
+	Since time.Time
+	// New and Old count the entries written per level name
+	New map[string]uint64
+	Old map[string]uint64
+	// NewFields and OldFields count the entries carrying each field key
+	NewFields map[string]uint64
+	OldFields map[string]uint64
+	// Missing lists the keys the old pipeline never wrote while the new
+	// one did, sorted
+	Missing []string
+}
+
+func newDualWriteReport() *DualWriteReport {
+	return &DualWriteReport{
+		Since:     time.Now(),
+		New:       make(map[string]uint64),
+		Old:       make(map[string]uint64),
+		NewFields: make(map[string]uint64),
+		OldFields: make(map[string]uint64),
+	}
+}
+
+var dualWrite = struct {
+	mu     sync.Mutex
+	report *DualWriteReport
+	// hooked lists the logrus loggers holding the counting hook
+	hooked map[*logrus.Logger]bool
+	stop   chan struct{}
+}{report: newDualWriteReport(), hooked: make(map[*logrus.Logger]bool)}
+
+// DualWriteStats returns the comparison since the last report
+func DualWriteStats() DualWriteReport {
+	dualWrite.mu.Lock()
+	defer dualWrite.mu.Unlock()
+	return dualWrite.report.snapshot()
+}
+
+// snapshot copies r and fills Missing, dualWrite.mu must be held
+func (r *DualWriteReport) snapshot() DualWriteReport {
+	copyCounts := func(m map[string]uint64) map[string]uint64 {
+		c := make(map[string]uint64, len(m))
+		for k, v := range m {
+			c[k] = v
+		}
+		return c
+	}
+	s := DualWriteReport{
+		Since:     r.Since,
+		New:       copyCounts(r.New),
+		Old:       copyCounts(r.Old),
+		NewFields: copyCounts(r.NewFields),
+		OldFields: copyCounts(r.OldFields),
+	}
+	for key := range r.NewFields {
+		if r.OldFields[key] == 0 {
+			s.Missing = append(s.Missing, key)
+		}
+	}
+	sort.Strings(s.Missing)
+	return s
+}
+
+// dualWriteHook counts what logrus writes
+type dualWriteHook struct{}
+
+func (dualWriteHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (dualWriteHook) Fire(e *logrus.Entry) error {
+	dualWrite.mu.Lock()
+	defer dualWrite.mu.Unlock()
+	dualWrite.report.Old[e.Level.String()]++
+	for key := range e.Data {
+		dualWrite.report.OldFields[key]++
+	}
+	return nil
+}
+
+// logrusLevel maps the levels, logrus exits or panics at its own fatal
+// and panic levels
+func logrusLevel(level zapcore.Level) logrus.Level {
+	switch rank := levelRank(level); {
+	case rank < levelRank(zapcore.InfoLevel):
+		return logrus.DebugLevel
+	case rank < levelRank(zapcore.WarnLevel):
+		return logrus.InfoLevel
+	case rank < levelRank(zapcore.ErrorLevel):
+		return logrus.WarnLevel
+	case rank < levelRank(zapcore.DPanicLevel):
+		return logrus.ErrorLevel
+	}
+	return logrus.FatalLevel
+}
+
+type logrusWriter struct {
+	log *logrus.Logger
+}
+
+func (w logrusWriter) WriteEntry(ent zapcore.Entry, fields []zapcore.Field, encoded []byte) error {
+	data := fieldMap(fields)
+	if ent.LoggerName != "" {
+		data["logger"] = ent.LoggerName
+	}
+
+	dualWrite.mu.Lock()
+	dualWrite.report.New[levelName(ent.Level)]++
+	for key := range data {
+		dualWrite.report.NewFields[key]++
+	}
+	dualWrite.mu.Unlock()
+
+	e := logrus.NewEntry(w.log).WithFields(logrus.Fields(data)).WithTime(ent.Time)
+	e.Log(logrusLevel(ent.Level), ent.Message)
+	return nil
+}
+
+func (w logrusWriter) Sync() error {
+	return nil
+}
+
+// newDualWriteCore feeds config.Logrus and starts the reports
+func newDualWriteCore(config Config) zapcore.Core {
+	dual := *config.DualWrite
+	if dual.ReportInterval <= 0 {
+		dual.ReportInterval = time.Minute
+	}
+
+	dualWrite.mu.Lock()
+	if !dualWrite.hooked[dual.Logrus] {
+		dual.Logrus.AddHook(dualWriteHook{})
+		dualWrite.hooked[dual.Logrus] = true
+	}
+	dualWrite.mu.Unlock()
+
+	startDualWriteReport(dual.ReportInterval)
+	return newEntryCore(newEncoder(config, EncodingJSON), logrusWriter{log: dual.Logrus}, newSinkLevelEnabler())
+}
+
+// startDualWriteReport replaces the running reporter, interval <= 0 only
+// stops it
+func startDualWriteReport(interval time.Duration) {
+	dualWrite.mu.Lock()
+	defer dualWrite.mu.Unlock()
+
+	if dualWrite.stop != nil {
+		close(dualWrite.stop)
+		dualWrite.stop = nil
+	}
+	if interval <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	dualWrite.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				dualWrite.mu.Lock()
+				r := dualWrite.report.snapshot()
+				dualWrite.report = newDualWriteReport()
+				dualWrite.mu.Unlock()
+				internalLog(zapcore.InfoLevel, "Dual write report",
+					zap.Any("new", r.New),
+					zap.Any("old", r.Old),
+					Strings("missing_fields", r.Missing),
+					Duration("window", time.Since(r.Since)))
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// secretSuffixes mark config fields whose value DumpConfig masks, "key"
+// covers APIKey, SharedKey and the bare Key of the incident sink
+var secretSuffixes = []string{"password", "secret", "key", "credentials"}
+
+func secretField(name string) bool {
+	name = strings.ToLower(name)
+	for _, suffix := range secretSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	levelType    = reflect.TypeOf(zapcore.Level(0))
+	configType   = reflect.TypeOf(Config{})
+)
+
+// DumpConfig returns the configuration in effect as plain values ready for
+// JSON, secrets are masked and functions or clients are shown by type.
+// "effective" holds what the config resolves to, e.g. the console encoding after
+// LOG_PRETTY and the levels set at runtime.
+func DumpConfig() map[string]interface{} {
+	config := *currentConfig()
+
+	namedLevelNames := make(map[string]string)
+	for name, level := range NamedLevels() {
+		namedLevelNames[name] = levelName(level)
+	}
+	return map[string]interface{}{
+		"config": dumpValue(reflect.ValueOf(config)),
+		"effective": map[string]interface{}{
+			"encoding":         config.encoding(),
+			"console_encoding": config.consoleEncoding(),
+			"level":            levelName(currentLevel()),
+			"named_levels":     namedLevelNames,
+			"transformers":     len(registeredTransformers()),
+			"caller":           needsCaller(config),
+		},
+	}
+}
+
+func dumpValue(v reflect.Value) interface{} {
+	switch v.Type() {
+	case durationType:
+		return time.Duration(v.Int()).String()
+	case levelType:
+		return levelName(zapcore.Level(v.Int()))
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return dumpValue(v.Elem())
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if s, ok := v.Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+		// config structs of this package are dumped, clients only by type
+		if v.Elem().Kind() != reflect.Struct || v.Type().Elem().PkgPath() == configType.PkgPath() {
+			return dumpValue(v.Elem())
+		}
+		return v.Type().String()
+	case reflect.Func, reflect.Chan:
+		if v.IsNil() {
+			return nil
+		}
+		return v.Type().String()
+	case reflect.Struct:
+		out := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			if secretField(f.Name) {
+				if !v.Field(i).IsZero() {
+					out[f.Name] = "****"
+				}
+				continue
+			}
+			out[f.Name] = dumpValue(v.Field(i))
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = dumpValue(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(dumpValue(iter.Key()))] = dumpValue(iter.Value())
+		}
+		return out
+	}
+	return v.Interface()
+}
+
+// ConfigHandler serves DumpConfig as JSON, mount it on an admin listener:
+//
+//	http.Handle("/debug/logger/config", logger.ConfigHandler())
+func ConfigHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := json.MarshalIndent(DumpConfig(), "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+}
@@ -0,0 +1,204 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"mime"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	textTemplate "text/template"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// EmailConfig configures the email sink, it collects the error and above
+// entries and mails them as one digest, an HTML table, every Interval.
+// Nothing is sent while no entry came in.
+type EmailConfig struct {
+	// Addr is the SMTP server, host:port
+	Addr string
+	// Auth authenticates to the server when not nil, e.g.
+	// smtp.PlainAuth("", user, password, host)
+	Auth smtp.Auth
+	From string
+	To   []string
+	// Subject is a text/template executed with the EmailDigest, default
+	// "[{{.Host}}] {{.Count}} errors logged"
+	Subject string
+	// Interval between two digests, default 15 minutes
+	Interval time.Duration
+	// MaxEntries caps the entries of a digest, the others are only
+	// counted, default 100
+	MaxEntries int
+}
+
+// EmailDigest is the data of the Subject template
+type EmailDigest struct {
+	Host string
+	// Count of entries logged, listed or not
+	Count int
+	Since time.Time
+	Until time.Time
+}
+
+type emailEntry struct {
+	Time    string
+	Level   string
+	Logger  string
+	Message string
+	Fields  string
+}
+
+var emailBody = template.Must(template.New("body").Parse(`<html><body>
+<p>{{.Digest.Count}} entries logged on {{.Digest.Host}} from {{.Digest.Since.Format "2006-01-02 15:04:05 MST"}} to {{.Digest.Until.Format "15:04:05 MST"}}{{if .Omitted}}, the last {{.Omitted}} are not listed{{end}}.</p>
+<table border="1" cellpadding="4" cellspacing="0" style="border-collapse:collapse;font-family:monospace;font-size:12px">
+<tr><th>Time</th><th>Level</th><th>Logger</th><th>Message</th><th>Fields</th></tr>
+{{range .Entries}}<tr><td>{{.Time}}</td><td>{{.Level}}</td><td>{{.Logger}}</td><td>{{.Message}}</td><td>{{.Fields}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+type emailWriter struct {
+	config  EmailConfig
+	subject *textTemplate.Template
+	host    string
+
+	mu      sync.Mutex
+	entries []emailEntry
+	count   int
+	since   time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newEmailWriter(config EmailConfig) (*emailWriter, error) {
+	if config.Addr == "" || config.From == "" || len(config.To) == 0 {
+		return nil, errors.New("Email sink without server, sender or recipient")
+	}
+	if config.Subject == "" {
+		config.Subject = "[{{.Host}}] {{.Count}} errors logged"
+	}
+	if config.Interval <= 0 {
+		config.Interval = 15 * time.Minute
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 100
+	}
+	subject, err := textTemplate.New("subject").Parse(config.Subject)
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+
+	w := &emailWriter{
+		config:  config,
+		subject: subject,
+		host:    host,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+func (w *emailWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.send(); err != nil {
+				internalLog(zapcore.ErrorLevel, "Failed send email digest", String("server", w.config.Addr), Err(err))
+			}
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *emailWriter) WriteEntry(ent zapcore.Entry, fields []zapcore.Field, encoded []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.count == 0 {
+		w.since = ent.Time
+	}
+	w.count++
+	if len(w.entries) >= w.config.MaxEntries {
+		return nil
+	}
+	var data []byte
+	if m := fieldMap(fields); len(m) > 0 {
+		data, _ = json.Marshal(m)
+	}
+	w.entries = append(w.entries, emailEntry{
+		Time:    ent.Time.Format("15:04:05.000"),
+		Level:   levelName(ent.Level),
+		Logger:  ent.LoggerName,
+		Message: ent.Message,
+		Fields:  string(data),
+	})
+	return nil
+}
+
+// send mails the digest of the entries collected since the last one
+func (w *emailWriter) send() error {
+	w.mu.Lock()
+	entries, count, since := w.entries, w.count, w.since
+	w.entries, w.count = nil, 0
+	w.mu.Unlock()
+	if count == 0 {
+		return nil
+	}
+
+	digest := EmailDigest{Host: w.host, Count: count, Since: since, Until: time.Now()}
+	var subject strings.Builder
+	if err := w.subject.Execute(&subject, digest); err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	err := emailBody.Execute(&body, struct {
+		Digest  EmailDigest
+		Entries []emailEntry
+		Omitted int
+	}{digest, entries, count - len(entries)})
+	if err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+	msg.WriteString("From: " + w.config.From + "\r\n")
+	msg.WriteString("To: " + strings.Join(w.config.To, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject.String()) + "\r\n")
+	msg.WriteString("Date: " + digest.Until.Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=utf-8\r\n")
+	msg.WriteString("X-Log-Entries: " + strconv.Itoa(count) + "\r\n\r\n")
+	msg.Write(body.Bytes())
+	return smtp.SendMail(w.config.Addr, w.config.Auth, w.config.From, w.config.To, msg.Bytes())
+}
+
+func (w *emailWriter) Sync() error {
+	return nil
+}
+
+// Close stops the timer and sends the last digest
+func (w *emailWriter) Close() error {
+	select {
+	case <-w.stop:
+		return nil
+	default:
+	}
+	close(w.stop)
+	<-w.done
+	return w.send()
+}
@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"os"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// Encodings accepted by Config.Encoding
+const (
+	EncodingJSON    = "json"
+	EncodingConsole = "console"
+	EncodingMsgpack = "msgpack"
+	EncodingCBOR    = "cbor"
+)
+
+var bufferPool = buffer.NewPool()
+
+func validEncoding(encoding string) bool {
+	switch encoding {
+	case EncodingJSON, EncodingConsole, EncodingMsgpack, EncodingCBOR, EncodingProtobuf,
+		EncodingCEF, EncodingLEEF, EncodingDev:
+		return true
+	}
+	return false
+}
+
+// encoding resolves Encoding and the legacy EncodeLogsAsJson switch
+func (c Config) encoding() string {
+	if c.Encoding != "" {
+		return c.Encoding
+	}
+	if c.EncodeLogsAsJson {
+		return EncodingJSON
+	}
+	return EncodingConsole
+}
+
+// consoleEncoding is the encoding of stdout and stderr, LOG_PRETTY=1 turns
+// it into EncodingDev, files and remote sinks keep encoding
+func (c Config) consoleEncoding() string {
+	if prettyEnabled() {
+		return EncodingDev
+	}
+	return c.encoding()
+}
+
+// isTerminal reports whether w is a character device, e.g. a tty
+func isTerminal(w interface{}) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func newEncoder(config Config, encoding string) zapcore.Encoder {
+	return newColorEncoder(config, encoding, true)
+}
+
+// newOutputEncoder is the encoder writing to output, colors are only used
+// when output is a terminal
+func newOutputEncoder(config Config, encoding string, output zapcore.WriteSyncer) zapcore.Encoder {
+	return newColorEncoder(config, encoding, isTerminal(output))
+}
+
+func newColorEncoder(config Config, encoding string, color bool) zapcore.Encoder {
+	encCfg := newEncoderConfig(config)
+	encCfg.EncodeLevel = levelEncoder(config, color && encoding == EncodingConsole)
+
+	switch encoding {
+	case EncodingJSON:
+		return zapcore.NewJSONEncoder(encCfg)
+	case EncodingMsgpack:
+		return newMapEncoder(encCfg, marshalMsgpack)
+	case EncodingCBOR:
+		return newMapEncoder(encCfg, marshalCBOR)
+	case EncodingProtobuf:
+		return newProtoEncoder(encCfg)
+	case EncodingCEF, EncodingLEEF:
+		return newCEFEncoder(encCfg, config.CEF, encoding == EncodingLEEF)
+	case EncodingDev:
+		return newDevEncoder(encCfg, config, color && !config.DisableColor && os.Getenv("NO_COLOR") == "")
+	}
+	return zapcore.NewConsoleEncoder(encCfg)
+}
+
+// mapEncoder collects an entry into a map and serializes it with marshal,
+// it backs the binary encodings where field order does not matter
+type mapEncoder struct {
+	*zapcore.MapObjectEncoder
+	cfg     zapcore.EncoderConfig
+	marshal func(map[string]interface{}) ([]byte, error)
+}
+
+func newMapEncoder(cfg zapcore.EncoderConfig, marshal func(map[string]interface{}) ([]byte, error)) *mapEncoder {
+	return &mapEncoder{MapObjectEncoder: zapcore.NewMapObjectEncoder(), cfg: cfg, marshal: marshal}
+}
+
+func (e *mapEncoder) Clone() zapcore.Encoder {
+	clone := newMapEncoder(e.cfg, e.marshal)
+	for k, v := range e.Fields {
+		clone.Fields[k] = v
+	}
+	return clone
+}
+
+// entryMap merges the entry metadata, the context and the call site fields
+func (e *mapEncoder) entryMap(ent zapcore.Entry, fields []zapcore.Field) map[string]interface{} {
+	m := make(map[string]interface{}, len(e.Fields)+len(fields)+6)
+	if e.cfg.TimeKey != "" {
+		m[e.cfg.TimeKey] = float64(ent.Time.UnixNano()) / 1e6
+	}
+	if e.cfg.LevelKey != "" {
+		m[e.cfg.LevelKey] = levelName(ent.Level)
+	}
+	if e.cfg.NameKey != "" && ent.LoggerName != "" {
+		m[e.cfg.NameKey] = ent.LoggerName
+	}
+	if e.cfg.CallerKey != "" && ent.Caller.Defined {
+		m[e.cfg.CallerKey] = ent.Caller.TrimmedPath()
+	}
+	if e.cfg.MessageKey != "" {
+		m[e.cfg.MessageKey] = ent.Message
+	}
+	if e.cfg.StacktraceKey != "" && ent.Stack != "" {
+		m[e.cfg.StacktraceKey] = ent.Stack
+	}
+	for k, v := range e.Fields {
+		m[k] = v
+	}
+	for k, v := range fieldMap(fields) {
+		m[k] = v
+	}
+	return m
+}
+
+func (e *mapEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	data, err := e.marshal(e.entryMap(ent, fields))
+	if err != nil {
+		return nil, err
+	}
+	buf := bufferPool.Get()
+	buf.Write(data)
+	return buf, nil
+}
@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPrettyOnlyChangesConsole(t *testing.T) {
+	t.Setenv("LOG_PRETTY", "1")
+	config := Config{EncodeLogsAsJson: true}
+
+	if got := config.consoleEncoding(); got != EncodingDev {
+		t.Errorf("console encoding %q, want %q", got, EncodingDev)
+	}
+	if got := config.encoding(); got != EncodingJSON {
+		t.Errorf("file encoding %q, want %q", got, EncodingJSON)
+	}
+	if got := (SinkConfig{Type: SinkFile}).encoding(config); got != EncodingJSON {
+		t.Errorf("file sink encoding %q, want %q", got, EncodingJSON)
+	}
+	if got := (SinkConfig{Type: SinkConsole}).encoding(config); got != EncodingDev {
+		t.Errorf("console sink encoding %q, want %q", got, EncodingDev)
+	}
+}
+
+func TestNoColorWithoutTerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if isTerminal(f) {
+		t.Fatal("regular file reported as terminal")
+	}
+	if enc := newOutputEncoder(Config{}, EncodingDev, f).(*devEncoder); enc.color {
+		t.Error("dev encoder colors a regular file")
+	}
+}
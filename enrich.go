@@ -0,0 +1,198 @@
+package logger
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Enricher adds fields derived from the value of an existing field, e.g.
+// the region of a client IP:
+//
+//	logger.RegisterEnricher(logger.Enricher{
+//		Field: "client_ip",
+//		Resolve: func(ctx context.Context, ip string) ([]zapcore.Field, error) {
+//			region, err := geo.Lookup(ctx, ip)
+//			return []zapcore.Field{logger.String("geo", region)}, err
+//		},
+//	})
+//
+// The enrichers run beneath the async queue, off the logging goroutines,
+// when Async is configured, inline otherwise. Results are cached per value,
+// a resolution outliving Timeout leaves the entry as is and still fills
+// the cache.
+type Enricher struct {
+	// Field is the key of the field read, context added by With included
+	Field string
+	// Resolve returns the fields to add for a value of Field
+	Resolve func(ctx context.Context, value string) ([]zapcore.Field, error)
+	// Timeout bounds the wait for Resolve, default 50ms, Resolve itself
+	// is canceled after CacheTTL
+	Timeout time.Duration
+	// CacheSize is the number of values cached, default 10000
+	CacheSize int
+	// CacheTTL is how long a result is cached, failures included, default
+	// 10 minutes
+	CacheTTL time.Duration
+}
+
+type enricher struct {
+	Enricher
+	mu    sync.Mutex
+	cache map[string]*list.Element
+	// lru orders the cached values, most recent first
+	lru *list.List
+	// pending holds the values being resolved, to resolve them once
+	pending map[string]chan struct{}
+}
+
+type enrichResult struct {
+	value   string
+	fields  []zapcore.Field
+	expires time.Time
+}
+
+var (
+	enrichersMu sync.RWMutex
+	enrichers   []*enricher
+)
+
+// RegisterEnricher appends e to the enrichers run on every entry, they
+// apply from the next Configure
+func RegisterEnricher(e Enricher) error {
+	if e.Field == "" || e.Resolve == nil {
+		return errors.New("Bad enricher field or resolver")
+	}
+	if e.Timeout <= 0 {
+		e.Timeout = 50 * time.Millisecond
+	}
+	if e.CacheSize <= 0 {
+		e.CacheSize = 10000
+	}
+	if e.CacheTTL <= 0 {
+		e.CacheTTL = 10 * time.Minute
+	}
+
+	enrichersMu.Lock()
+	enrichers = append(enrichers, &enricher{
+		Enricher: e,
+		cache:    make(map[string]*list.Element),
+		lru:      list.New(),
+		pending:  make(map[string]chan struct{}),
+	})
+	enrichersMu.Unlock()
+	return nil
+}
+
+func registeredEnrichers() []*enricher {
+	enrichersMu.RLock()
+	defer enrichersMu.RUnlock()
+	return append([]*enricher(nil), enrichers...)
+}
+
+// cached returns the cached fields of value, ok is false on a miss
+func (e *enricher) cached(value string) ([]zapcore.Field, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	el, ok := e.cache[value]
+	if !ok {
+		return nil, false
+	}
+	r := el.Value.(*enrichResult)
+	if time.Now().After(r.expires) {
+		e.lru.Remove(el)
+		delete(e.cache, value)
+		return nil, false
+	}
+	e.lru.MoveToFront(el)
+	return r.fields, true
+}
+
+func (e *enricher) store(value string, fields []zapcore.Field) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if el, ok := e.cache[value]; ok {
+		e.lru.Remove(el)
+	}
+	e.cache[value] = e.lru.PushFront(&enrichResult{value: value, fields: fields, expires: time.Now().Add(e.CacheTTL)})
+	for e.lru.Len() > e.CacheSize {
+		oldest := e.lru.Back()
+		e.lru.Remove(oldest)
+		delete(e.cache, oldest.Value.(*enrichResult).value)
+	}
+}
+
+// resolve returns the fields of value, waiting at most Timeout
+func (e *enricher) resolve(value string) []zapcore.Field {
+	if fields, ok := e.cached(value); ok {
+		return fields
+	}
+
+	e.mu.Lock()
+	done, running := e.pending[value]
+	if !running {
+		done = make(chan struct{})
+		e.pending[value] = done
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), e.CacheTTL)
+			defer cancel()
+			fields, err := e.Resolve(ctx, value)
+			if err != nil {
+				internalLog(zapcore.WarnLevel, "Failed enrich entry", String("field", e.Field), Err(err))
+			}
+			e.store(value, fields)
+			e.mu.Lock()
+			delete(e.pending, value)
+			e.mu.Unlock()
+			close(done)
+		}()
+	}
+	e.mu.Unlock()
+
+	timer := time.NewTimer(e.Timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		fields, _ := e.cached(value)
+		return fields
+	case <-timer.C:
+		return nil
+	}
+}
+
+// fieldValue renders the value of the field key, ok is false without one
+func fieldValue(key string, lists ...[]zapcore.Field) (string, bool) {
+	for _, list := range lists {
+		for i := len(list) - 1; i >= 0; i-- {
+			f := list[i]
+			if f.Key != key {
+				continue
+			}
+			if f.Type == zapcore.StringType {
+				return f.String, true
+			}
+			if v, ok := fieldMap([]zapcore.Field{f})[key]; ok {
+				return fmt.Sprint(v), true
+			}
+		}
+	}
+	return "", false
+}
+
+func newEnrichProcess(list []*enricher) processFunc {
+	return func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+		for _, e := range list {
+			if value, ok := fieldValue(e.Field, fields, context); ok && value != "" {
+				fields = append(fields, e.resolve(value)...)
+			}
+		}
+		return ent, fields, true
+	}
+}
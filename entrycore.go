@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// entryWriter is implemented by sinks that need the entry and its fields
+// and not only the encoded bytes, e.g. to route by level or field value
+type entryWriter interface {
+	WriteEntry(ent zapcore.Entry, fields []zapcore.Field, encoded []byte) error
+	Sync() error
+}
+
+// entryCore is a zapcore.Core feeding an entryWriter
+type entryCore struct {
+	zapcore.LevelEnabler
+	enc    zapcore.Encoder
+	fields []zapcore.Field
+	out    entryWriter
+}
+
+func newEntryCore(enc zapcore.Encoder, out entryWriter, enab zapcore.LevelEnabler) zapcore.Core {
+	return &entryCore{LevelEnabler: enab, enc: enc, out: out}
+}
+
+func (c *entryCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(append(all, c.fields...), fields...)
+	return &entryCore{LevelEnabler: c.LevelEnabler, enc: enc, fields: all, out: c.out}
+}
+
+func (c *entryCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *entryCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	all := fields
+	if len(c.fields) > 0 {
+		all = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+		all = append(append(all, c.fields...), fields...)
+	}
+	return c.out.WriteEntry(ent, all, buf.Bytes())
+}
+
+func (c *entryCore) Sync() error {
+	return c.out.Sync()
+}
+
+// fieldMap renders fields into plain go values
+func fieldMap(fields []zapcore.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	return enc.Fields
+}
+
+// expandTemplate replaces {level}, {logger} and {<field>} placeholders,
+// placeholders without a value are replaced by def
+func expandTemplate(tmpl string, ent zapcore.Entry, fields map[string]interface{}, def string) string {
+	if !strings.Contains(tmpl, "{") {
+		return tmpl
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			break
+		}
+		b.WriteString(tmpl[:start])
+		b.WriteString(templateValue(tmpl[start+1:start+end], ent, fields, def))
+		tmpl = tmpl[start+end+1:]
+	}
+	b.WriteString(tmpl)
+
+	return b.String()
+}
+
+func templateValue(key string, ent zapcore.Entry, fields map[string]interface{}, def string) string {
+	switch key {
+	case "level":
+		return levelName(ent.Level)
+	case "logger":
+		if ent.LoggerName != "" {
+			return ent.LoggerName
+		}
+		return def
+	}
+	if v, ok := fields[key]; ok {
+		if s := fmt.Sprint(v); s != "" {
+			return s
+		}
+	}
+	return def
+}
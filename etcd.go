@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"context"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// WatchEtcd applies the RemoteSettings stored under key and every change
+// to it, until Close:
+//
+//	c := logger.WatchEtcd(client, "/config/logging/"+service)
+//	defer c.Close()
+func WatchEtcd(client *clientv3.Client, key string) *RemoteController {
+	c := newRemoteController("etcd", key)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-c.stop
+		cancel()
+	}()
+	go func() {
+		defer close(c.done)
+		for {
+			c.watchEtcd(ctx, client)
+			if !c.wait(remoteRetryInterval) {
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// watchEtcd reads the key then follows its revisions until the watch fails
+func (c *RemoteController) watchEtcd(ctx context.Context, client *clientv3.Client) {
+	resp, err := client.Get(ctx, c.key)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.failed("Failed read remote logging config", err)
+		}
+		return
+	}
+	if len(resp.Kvs) > 0 {
+		c.apply(resp.Kvs[0].Value)
+	}
+
+	for wr := range client.Watch(ctx, c.key, clientv3.WithRev(resp.Header.Revision+1)) {
+		if err := wr.Err(); err != nil {
+			c.failed("Failed watch remote logging config", err)
+			return
+		}
+		for _, ev := range wr.Events {
+			if ev.Type == clientv3.EventTypeDelete {
+				c.deleted()
+			} else {
+				c.apply(ev.Kv.Value)
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// EventSchema describes the fields of a structured event
+type EventSchema struct {
+	// Required lists the field keys every event must carry
+	Required []string
+	// Types maps field keys to "string", "number", "bool", "object", "array"
+	// or "time", fields missing here are not type checked
+	Types map[string]string
+	// Strict reports fields that are neither required nor typed
+	Strict bool
+}
+
+var (
+	eventSchemasMu sync.RWMutex
+	eventSchemas   = map[string]EventSchema{}
+)
+
+// RegisterEvent registers the schema Event validates name against
+func RegisterEvent(name string, schema EventSchema) error {
+	if name == "" {
+		return errors.New("Bad event name")
+	}
+	for key, typ := range schema.Types {
+		if !validJSONType(typ) {
+			return errors.New("Bad type " + typ + " for event field " + key)
+		}
+	}
+
+	eventSchemasMu.Lock()
+	eventSchemas[name] = schema
+	eventSchemasMu.Unlock()
+	return nil
+}
+
+// Event logs a structured event at the info level, the entry carries the
+// event name under the stable "event" key so pipelines can rely on it
+// instead of message text. When a schema is registered for name, problems
+// are listed in an "event_violations" field, the event is still logged.
+func (l *Log) Event(name string, fields ...zapcore.Field) {
+	if ce := l.zap().Check(zapcore.InfoLevel, name); ce != nil {
+		l.write(ce, eventFields(name, fields))
+	}
+}
+
+// eventFields adds the event name and the schema violations to fields
+func eventFields(name string, fields []zapcore.Field) []zapcore.Field {
+	eventSchemasMu.RLock()
+	schema, ok := eventSchemas[name]
+	eventSchemasMu.RUnlock()
+
+	if ok {
+		if violations := schema.validate(fieldMap(fields)); len(violations) > 0 {
+			fields = append(fields, Strings("event_violations", violations))
+		}
+	}
+	return append(fields, String("event", name))
+}
+
+func (s EventSchema) validate(values map[string]interface{}) []string {
+	var violations []string
+	for _, key := range s.Required {
+		if _, ok := values[key]; !ok {
+			violations = append(violations, "missing "+key)
+		}
+	}
+	for key, value := range values {
+		typ, typed := s.Types[key]
+		if typed {
+			if got := jsonType(value); got != typ {
+				violations = append(violations, key+" is "+got+" not "+typ)
+			}
+		} else if s.Strict && !contains(s.Required, key) {
+			violations = append(violations, "unexpected "+key)
+		}
+	}
+	sort.Strings(violations)
+	return violations
+}
+
+func validJSONType(typ string) bool {
+	switch typ {
+	case "string", "number", "bool", "object", "array", "time", "null":
+		return true
+	}
+	return false
+}
+
+// jsonType names the type of a value rendered by the map encoder
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string, []byte:
+		return "string"
+	case bool:
+		return "bool"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr,
+		float32, float64, complex64, complex128, time.Duration:
+		return "number"
+	case time.Time:
+		return "time"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
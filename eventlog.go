@@ -0,0 +1,25 @@
+package logger
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// EventLogConfig configures the Windows Event Log sink, it is only
+// available on windows, configuring it elsewhere fails sink creation
+type EventLogConfig struct {
+	// Source is the event source name shown in the Event Viewer
+	Source string
+	// Register installs Source in the registry when missing, requires admin rights
+	Register bool
+	// MinLevel is the lowest level written to the Event Log, default WarnLevel
+	MinLevel *zapcore.Level
+	// EventID is the id attached to every event, default 1
+	EventID uint32
+}
+
+func (c EventLogConfig) minLevel() zapcore.Level {
+	if c.MinLevel == nil {
+		return zapcore.WarnLevel
+	}
+	return *c.MinLevel
+}
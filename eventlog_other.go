@@ -0,0 +1,27 @@
+//go:build !windows
+
+package logger
+
+import (
+	"errors"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type eventLogWriter struct{}
+
+func newEventLogWriter(config EventLogConfig) (*eventLogWriter, error) {
+	return nil, errors.New("Event log is only supported on windows")
+}
+
+func (w *eventLogWriter) WriteEntry(ent zapcore.Entry, fields []zapcore.Field, encoded []byte) error {
+	return nil
+}
+
+func (w *eventLogWriter) Sync() error {
+	return nil
+}
+
+func (w *eventLogWriter) Close() error {
+	return nil
+}
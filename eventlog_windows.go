@@ -0,0 +1,59 @@
+//go:build windows
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+type eventLogWriter struct {
+	log *eventlog.Log
+	eid uint32
+}
+
+func newEventLogWriter(config EventLogConfig) (*eventLogWriter, error) {
+	if config.Source == "" {
+		return nil, errors.New("Bad event log source")
+	}
+	if config.EventID == 0 {
+		config.EventID = 1
+	}
+	if config.Register {
+		err := eventlog.InstallAsEventCreate(config.Source, eventlog.Error|eventlog.Warning|eventlog.Info)
+		if err != nil && !strings.Contains(err.Error(), "exists") {
+			return nil, err
+		}
+	}
+
+	log, err := eventlog.Open(config.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventLogWriter{log: log, eid: config.EventID}, nil
+}
+
+func (w *eventLogWriter) WriteEntry(ent zapcore.Entry, fields []zapcore.Field, encoded []byte) error {
+	msg := string(bytes.TrimRight(encoded, "\r\n"))
+	switch {
+	case levelAtLeast(ent.Level, zapcore.ErrorLevel):
+		return w.log.Error(w.eid, msg)
+	case levelAtLeast(ent.Level, zapcore.WarnLevel):
+		return w.log.Warning(w.eid, msg)
+	default:
+		return w.log.Info(w.eid, msg)
+	}
+}
+
+func (w *eventLogWriter) Sync() error {
+	return nil
+}
+
+func (w *eventLogWriter) Close() error {
+	return w.log.Close()
+}
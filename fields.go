@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// structField is one tagged field of a struct
+type structField struct {
+	index     int
+	name      string
+	omitempty bool
+	nested    *structPlan
+}
+
+type structPlan struct {
+	fields []structField
+}
+
+var (
+	structPlans   sync.Map // reflect.Type -> *structPlan
+	structPlansMu sync.Mutex
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	objectMarshalerType = reflect.TypeOf((*zapcore.ObjectMarshaler)(nil)).Elem()
+)
+
+// Fields turns a struct (or pointer to struct) into fields using `log` tags,
+// only tagged fields are emitted:
+//
+//	type Request struct {
+//		ID     string `log:"request_id"`
+//		User   User   `log:"user"`           // nested struct, logged as an object
+//		Token  string `log:"-"`              // never logged
+//		Region string `log:"region,omitempty"`
+//	}
+//
+// The tag layout of every type is computed once and cached.
+func Fields(v interface{}) []zapcore.Field {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	plan := planFor(rv.Type())
+	fields := make([]zapcore.Field, 0, len(plan.fields))
+	for _, sf := range plan.fields {
+		fv := rv.Field(sf.index)
+		if sf.omitempty && fv.IsZero() {
+			continue
+		}
+		fields = append(fields, structFieldValue(sf, fv))
+	}
+	return fields
+}
+
+func structFieldValue(sf structField, fv reflect.Value) zapcore.Field {
+	if sf.nested != nil {
+		for fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				return zap.Reflect(sf.name, nil)
+			}
+			fv = fv.Elem()
+		}
+		return zap.Object(sf.name, structMarshaler{plan: sf.nested, value: fv})
+	}
+	return zap.Any(sf.name, fv.Interface())
+}
+
+func planFor(t reflect.Type) *structPlan {
+	if plan, ok := structPlans.Load(t); ok {
+		return plan.(*structPlan)
+	}
+
+	structPlansMu.Lock()
+	defer structPlansMu.Unlock()
+	return buildPlan(t, make(map[reflect.Type]*structPlan))
+}
+
+// buildPlan publishes a plan only once it is complete, building tracks the
+// plans in progress so self referencing types terminate
+func buildPlan(t reflect.Type, building map[reflect.Type]*structPlan) *structPlan {
+	if plan, ok := structPlans.Load(t); ok {
+		return plan.(*structPlan)
+	}
+	if plan, ok := building[t]; ok {
+		return plan
+	}
+	plan := &structPlan{}
+	building[t] = plan
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("log")
+		if !ok || tag == "-" || f.PkgPath != "" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		sf := structField{index: i, name: name, omitempty: opts == "omitempty"}
+
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft != timeType &&
+			!f.Type.Implements(objectMarshalerType) && !reflect.PtrTo(ft).Implements(objectMarshalerType) {
+			sf.nested = buildPlan(ft, building)
+		}
+		plan.fields = append(plan.fields, sf)
+	}
+	structPlans.Store(t, plan)
+
+	return plan
+}
+
+// structMarshaler logs a nested struct as an object following its plan
+type structMarshaler struct {
+	plan  *structPlan
+	value reflect.Value
+}
+
+func (m structMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, sf := range m.plan.fields {
+		fv := m.value.Field(sf.index)
+		if sf.omitempty && fv.IsZero() {
+			continue
+		}
+		structFieldValue(sf, fv).AddTo(enc)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// FileAttrConfig sets the owner and the extended attributes of the log
+// directory and logfiles, e.g. when the process creates them as root
+// before dropping its privileges. lumberjack keeps the owner of the logfile
+// across rotations, the attributes are set again on every new logfile.
+//
+// Modes: the logfiles are created with FileMode and the directory with
+// DirMode, set with chmod so the umask does not apply, lumberjack then
+// keeps the mode across rotations. Left at 0 the logfile is created 0600
+// and the directory 0755, both minus the umask. On windows only the owner
+// write bit is honored, it makes the file read-only.
+type FileAttrConfig struct {
+	// FileMode is the mode of the logfiles, e.g. 0640
+	FileMode os.FileMode
+	// DirMode is the mode of the log directory when it is created, e.g. 0750
+	DirMode os.FileMode
+	// ChmodExisting also applies FileMode and DirMode to the directory and
+	// logfiles already there, rotated files included
+	ChmodExisting bool
+	// Owner and Group are names or numeric ids, unchanged when empty
+	Owner string
+	Group string
+	// XAttrs are extended attributes, e.g. {"user.retention": "audit"},
+	// linux only
+	XAttrs map[string]string
+	// SELinuxLabel is the security.selinux attribute, e.g.
+	// "system_u:object_r:var_log_t:s0", linux only
+	SELinuxLabel string
+	// AppendOnly sets the append-only flag (chattr +a) on the active
+	// logfile, cleared for the rotation, linux only and needing
+	// CAP_LINUX_IMMUTABLE
+	AppendOnly bool
+	// AppendOnlyRotated keeps the flag on the rotated files, which then
+	// cannot be deleted by MaxBackups, MaxAge, Retention or Archive until
+	// an administrator clears it
+	AppendOnlyRotated bool
+}
+
+// ids resolves the owner and group, -1 leaves them unchanged
+func (c FileAttrConfig) ids() (int, int, error) {
+	uid, gid := -1, -1
+	if c.Owner != "" {
+		if id, err := strconv.Atoi(c.Owner); err == nil {
+			uid = id
+		} else if u, err := user.Lookup(c.Owner); err == nil {
+			uid, _ = strconv.Atoi(u.Uid)
+		} else {
+			return 0, 0, err
+		}
+	}
+	if c.Group != "" {
+		if id, err := strconv.Atoi(c.Group); err == nil {
+			gid = id
+		} else if g, err := user.LookupGroup(c.Group); err == nil {
+			gid, _ = strconv.Atoi(g.Gid)
+		} else {
+			return 0, 0, err
+		}
+	}
+	return uid, gid, nil
+}
+
+// apply sets the attributes of path, the failures are reported together
+func (c FileAttrConfig) apply(path string) error {
+	var errs []error
+	uid, gid, err := c.ids()
+	if err != nil {
+		errs = append(errs, err)
+	} else if uid != -1 || gid != -1 {
+		if err := os.Chown(path, uid, gid); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for name, value := range c.XAttrs {
+		if err := setXattr(path, name, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.SELinuxLabel != "" {
+		if err := setXattr(path, "security.selinux", c.SELinuxLabel); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return errors.New(strings.Join(msgs, ", "))
+}
+
+// chmod sets mode on path, unless it is 0
+func chmod(path string, mode os.FileMode) {
+	if mode == 0 {
+		return
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		internalLog(zapcore.WarnLevel, "Failed set log file mode", String("path", path), String("mode", mode.String()), Err(err))
+	}
+}
+
+// createLogDir creates the log directory with the mode of attrs
+func createLogDir(attrs *FileAttrConfig, dir string) error {
+	mode := os.FileMode(0755)
+	if attrs != nil && attrs.DirMode != 0 {
+		mode = attrs.DirMode
+	}
+	_, err := os.Stat(dir)
+	created := os.IsNotExist(err)
+	if err := os.MkdirAll(dir, mode); err != nil {
+		return err
+	}
+	if attrs != nil && (created || attrs.ChmodExisting) {
+		chmod(dir, attrs.DirMode)
+	}
+	return nil
+}
+
+// applyFileAttrs applies attrs to path when not nil, reporting failures
+func applyFileAttrs(attrs *FileAttrConfig, path string) {
+	if attrs == nil {
+		return
+	}
+	if err := attrs.apply(path); err != nil {
+		internalLog(zapcore.WarnLevel, "Failed set log file attributes", String("path", path), Err(err))
+	}
+}
@@ -0,0 +1,11 @@
+//go:build linux
+
+package logger
+
+import (
+	"syscall"
+)
+
+func setXattr(path, name, value string) error {
+	return syscall.Setxattr(path, name, []byte(value), 0)
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package logger
+
+import (
+	"errors"
+)
+
+func setXattr(path, name, value string) error {
+	return errors.New("Extended attributes are only supported on linux")
+}
@@ -0,0 +1,30 @@
+//go:build !windows
+
+package logger
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+// withUmask sets the process umask for the duration of the test
+func withUmask(t *testing.T, mask int) {
+	old := syscall.Umask(mask)
+	t.Cleanup(func() { syscall.Umask(old) })
+}
+
+// defaultDirMode is the mode of a directory created without DirMode
+func defaultDirMode(umask os.FileMode) os.FileMode {
+	return 0755 &^ umask
+}
+
+// wantDirMode is the mode of a directory chmod-ed to mode
+func wantDirMode(mode os.FileMode) os.FileMode {
+	return mode
+}
+
+// wantFileMode is the mode of a file chmod-ed to mode
+func wantFileMode(mode os.FileMode) os.FileMode {
+	return mode
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/natefinch/lumberjack"
+)
+
+func fileMode(t *testing.T, path string) os.FileMode {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Mode().Perm()
+}
+
+func TestCreateLogDirMode(t *testing.T) {
+	withUmask(t, 0077)
+	tests := []struct {
+		name  string
+		attrs *FileAttrConfig
+		want  os.FileMode
+	}{
+		{"default", nil, defaultDirMode(0077)},
+		{"DirMode ignores umask", &FileAttrConfig{DirMode: 0750}, wantDirMode(0750)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "logs")
+			if err := createLogDir(tt.attrs, dir); err != nil {
+				t.Fatal(err)
+			}
+			if got := fileMode(t, dir); got != tt.want {
+				t.Errorf("mode %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateLogDirExisting(t *testing.T) {
+	for _, chmodExisting := range []bool{false, true} {
+		dir := t.TempDir()
+		if err := os.Chmod(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := createLogDir(&FileAttrConfig{DirMode: 0750, ChmodExisting: chmodExisting}, dir); err != nil {
+			t.Fatal(err)
+		}
+		want := wantDirMode(0700)
+		if chmodExisting {
+			want = wantDirMode(0750)
+		}
+		if got := fileMode(t, dir); got != want {
+			t.Errorf("ChmodExisting %v: mode %v, want %v", chmodExisting, got, want)
+		}
+	}
+}
+
+func TestLogfileMode(t *testing.T) {
+	withUmask(t, 0077)
+	for _, mode := range []os.FileMode{0640, 0444} {
+		name := filepath.Join(t.TempDir(), "app.log")
+		newRollingFileWriter(&lumberjack.Logger{Filename: name}, &FileAttrConfig{FileMode: mode})
+		if got, want := fileMode(t, name), wantFileMode(mode); got != want {
+			t.Errorf("FileMode %v: mode %v, want %v", mode, got, want)
+		}
+		os.Chmod(name, 0600)
+	}
+}
+
+func TestLogfileChmodExisting(t *testing.T) {
+	for _, chmodExisting := range []bool{false, true} {
+		dir := t.TempDir()
+		name := filepath.Join(dir, "app.log")
+		rotated := filepath.Join(dir, "app-2024-01-02T03-04-05.000.log")
+		for _, path := range []string{name, rotated} {
+			if err := os.WriteFile(path, nil, 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		newRollingFileWriter(&lumberjack.Logger{Filename: name}, &FileAttrConfig{FileMode: 0444, ChmodExisting: chmodExisting})
+
+		want := wantFileMode(0600)
+		if chmodExisting {
+			want = wantFileMode(0444)
+		}
+		for _, path := range []string{name, rotated} {
+			if got := fileMode(t, path); got != want {
+				t.Errorf("ChmodExisting %v: %s mode %v, want %v", chmodExisting, filepath.Base(path), got, want)
+			}
+			// let TempDir remove the read-only files on windows
+			os.Chmod(path, 0600)
+		}
+	}
+}
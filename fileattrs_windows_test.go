@@ -0,0 +1,32 @@
+//go:build windows
+
+package logger
+
+import (
+	"os"
+	"testing"
+)
+
+// withUmask does nothing, windows has no umask
+func withUmask(t *testing.T, mask int) {}
+
+// defaultDirMode is the mode of a directory created without DirMode,
+// windows reports directories 0777
+func defaultDirMode(umask os.FileMode) os.FileMode {
+	return 0777
+}
+
+// wantDirMode is the mode of a directory chmod-ed to mode, windows only
+// keeps the read-only attribute, which it ignores on directories
+func wantDirMode(mode os.FileMode) os.FileMode {
+	return 0777
+}
+
+// wantFileMode is the mode of a file chmod-ed to mode, only the owner
+// write bit is honored
+func wantFileMode(mode os.FileMode) os.FileMode {
+	if mode&0200 == 0 {
+		return 0444
+	}
+	return 0666
+}
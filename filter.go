@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Filter drops or rewrites the entries matching all of its conditions, an
+// empty condition matches everything:
+//
+//	logger.Filter{
+//		Message:  regexp.MustCompile("connection reset by peer"),
+//		MaxLevel: zapcore.InfoLevel,
+//	}
+type Filter struct {
+	// Message matches the entry message
+	Message *regexp.Regexp
+	// HasFields lists keys the entry must carry, context added by With included
+	HasFields []string
+	// Package matches callers in the package or below it, such as
+	// github.com/acme/svc/db, caller information is collected when set
+	Package string
+	// MaxLevel matches entries at or below this level, every level when nil
+	MaxLevel *zapcore.Level
+	// Modify rewrites the matching entries, they are dropped when nil
+	Modify func(ent zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field)
+}
+
+func (f *Filter) match(ent zapcore.Entry, context, fields []zapcore.Field) bool {
+	if f.MaxLevel != nil && levelRank(ent.Level) > levelRank(*f.MaxLevel) {
+		return false
+	}
+	if f.Message != nil && !f.Message.MatchString(ent.Message) {
+		return false
+	}
+	if f.Package != "" {
+		if !ent.Caller.Defined {
+			return false
+		}
+		pkg := packagePath(ent.Caller.Function)
+		if pkg != f.Package && !strings.HasPrefix(pkg, f.Package+"/") {
+			return false
+		}
+	}
+	for _, key := range f.HasFields {
+		if !hasField(key, context) && !hasField(key, fields) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasField(key string, fields []zapcore.Field) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// filtersNeedCaller reports whether a filter matches on the caller package
+func filtersNeedCaller(filters []Filter) bool {
+	for _, f := range filters {
+		if f.Package != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// newFilterProcess applies every matching filter in order, the first
+// dropping one ends the entry
+func newFilterProcess(filters []Filter) processFunc {
+	return func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+		for i := range filters {
+			f := &filters[i]
+			if !f.match(ent, context, fields) {
+				continue
+			}
+			if f.Modify == nil {
+				return ent, fields, false
+			}
+			ent, fields = f.Modify(ent, fields)
+		}
+		return ent, fields, true
+	}
+}
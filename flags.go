@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"flag"
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+	"go.uber.org/zap/zapcore"
+)
+
+// flagValues holds the command line values bound by BindFlags
+type flagValues struct {
+	level      zapcore.Level
+	levelSet   bool
+	file       string
+	format     string
+	maxSize    int
+	maxBackups int
+	maxAge     int
+	caller     bool
+}
+
+var boundFlags flagValues
+
+// levelValue parses --log-level, extra levels included
+type levelValue zapcore.Level
+
+func (l *levelValue) Set(s string) error {
+	level, err := parseLevel(s)
+	if err != nil {
+		return err
+	}
+	*l = levelValue(level)
+	// --log-level info must apply, see Config.LevelSet
+	boundFlags.levelSet = true
+	return nil
+}
+
+func (l *levelValue) String() string {
+	return levelName(zapcore.Level(*l))
+}
+
+func (l *levelValue) Type() string {
+	return "level"
+}
+
+const (
+	levelUsage      = "lowest level logged: debug, info, warn, error..."
+	fileUsage       = "logfile path, stdout only when empty"
+	formatUsage     = "log encoding: json, console, dev, msgpack..."
+	maxSizeUsage    = "max size in MB of the logfile before it is rolled"
+	maxBackupsUsage = "max number of rolled logfiles to keep"
+	maxAgeUsage     = "max age in days to keep a rolled logfile"
+	callerUsage     = "add the file:line of the call site to every entry"
+)
+
+// BindFlags registers --log-level, --log-file, --log-format, --log-max-size,
+// --log-max-backups, --log-max-age and --log-caller on fs, FromFlags
+// builds the Config once fs is parsed:
+//
+//	logger.BindFlags(flag.CommandLine)
+//	flag.Parse()
+//	config, err := logger.FromFlags()
+func BindFlags(fs *flag.FlagSet) {
+	fs.Var((*levelValue)(&boundFlags.level), "log-level", levelUsage)
+	fs.StringVar(&boundFlags.file, "log-file", "", fileUsage)
+	fs.StringVar(&boundFlags.format, "log-format", "", formatUsage)
+	fs.IntVar(&boundFlags.maxSize, "log-max-size", 100, maxSizeUsage)
+	fs.IntVar(&boundFlags.maxBackups, "log-max-backups", 0, maxBackupsUsage)
+	fs.IntVar(&boundFlags.maxAge, "log-max-age", 0, maxAgeUsage)
+	fs.BoolVar(&boundFlags.caller, "log-caller", false, callerUsage)
+}
+
+// BindPFlags is BindFlags for pflag and cobra
+func BindPFlags(fs *pflag.FlagSet) {
+	fs.Var((*levelValue)(&boundFlags.level), "log-level", levelUsage)
+	fs.StringVar(&boundFlags.file, "log-file", "", fileUsage)
+	fs.StringVar(&boundFlags.format, "log-format", "", formatUsage)
+	fs.IntVar(&boundFlags.maxSize, "log-max-size", 100, maxSizeUsage)
+	fs.IntVar(&boundFlags.maxBackups, "log-max-backups", 0, maxBackupsUsage)
+	fs.IntVar(&boundFlags.maxAge, "log-max-age", 0, maxAgeUsage)
+	fs.BoolVar(&boundFlags.caller, "log-caller", false, callerUsage)
+}
+
+// FromFlags returns the Config set by the flags of BindFlags, the result is
+// validated, it still needs Configure
+func FromFlags() (Config, error) {
+	config := Config{
+		Encoding: boundFlags.format,
+		LogLevel: boundFlags.level,
+		LevelSet: boundFlags.levelSet,
+		Caller:   boundFlags.caller,
+	}
+	if boundFlags.file != "" {
+		config.FileLoggingEnabled = true
+		config.Directory = filepath.Dir(boundFlags.file)
+		config.Filename = filepath.Base(boundFlags.file)
+		config.MaxSize = boundFlags.maxSize
+		config.MaxBackups = boundFlags.maxBackups
+		config.MaxAge = boundFlags.maxAge
+	}
+	return config, config.Validate()
+}
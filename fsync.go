@@ -0,0 +1,233 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/natefinch/lumberjack"
+	"go.uber.org/zap/zapcore"
+)
+
+// FsyncConfig sets when the logfile is flushed to disk, the zero value
+// leaves it to the kernel, options combine
+type FsyncConfig struct {
+	// Entries syncs after every N entries, 0 disables
+	Entries int
+	// Interval syncs periodically, 0 disables
+	Interval time.Duration
+	// OnError syncs after every error and above entry
+	OnError bool
+}
+
+// rollingFile fsyncs the lumberjack logfile, lumberjack keeps its file
+// private so Sync reopens it by path, which syncs the same inode
+type rollingFile struct {
+	*lumberjack.Logger
+	// size tracks the logfile like lumberjack does to report rotations
+	size *atomic.Int64
+	// attrs are set on every new logfile when not nil
+	attrs *FileAttrConfig
+}
+
+func newRollingFileWriter(l *lumberjack.Logger, attrs *FileAttrConfig) rollingFile {
+	f := rollingFile{Logger: l, size: new(atomic.Int64), attrs: attrs}
+	created := false
+	if info, err := os.Stat(l.Filename); err == nil {
+		f.size.Store(info.Size())
+	} else if attrs != nil {
+		// create the logfile now, lumberjack keeps its owner and mode on rotation
+		mode := os.FileMode(0600)
+		if attrs.FileMode != 0 {
+			mode = attrs.FileMode
+		}
+		if file, err := os.OpenFile(l.Filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, mode); err == nil {
+			file.Close()
+			created = true
+		}
+	}
+	if attrs != nil && attrs.ChmodExisting && attrs.FileMode != 0 {
+		dir, name := filepath.Split(l.Filename)
+		if dir == "" {
+			dir = "."
+		}
+		if files, err := rotatedFiles(dir, name); err == nil {
+			for _, rotated := range files {
+				chmod(rotated.Path, attrs.FileMode)
+			}
+		}
+	}
+	f.newLogfile(created || attrs != nil && attrs.ChmodExisting)
+	return f
+}
+
+func (f rollingFile) maxSize() int64 {
+	max := int64(f.MaxSize) << 20
+	if max == 0 {
+		// lumberjack default
+		max = 100 << 20
+	}
+	return max
+}
+
+func (f rollingFile) appendOnly() bool {
+	return f.attrs != nil && f.attrs.AppendOnly
+}
+
+// newLogfile sets the attributes of the active logfile, its mode too
+// with mode set
+func (f rollingFile) newLogfile(mode bool) {
+	if mode && f.attrs != nil {
+		chmod(f.Filename, f.attrs.FileMode)
+	}
+	applyFileAttrs(f.attrs, f.Filename)
+	if f.appendOnly() {
+		if err := setAppendOnly(f.Filename, true); err != nil {
+			internalLog(zapcore.WarnLevel, "Failed set log file append-only", String("file", f.Filename), Err(err))
+		}
+	}
+}
+
+// rotated handles the logfile just rotated by lumberjack
+func (f rollingFile) rotated() {
+	internalLog(zapcore.InfoLevel, "Log file rotated", String("file", f.Filename))
+	f.newLogfile(true)
+	if f.appendOnly() && f.attrs.AppendOnlyRotated {
+		dir, name := filepath.Split(f.Filename)
+		if dir == "" {
+			dir = "."
+		}
+		if files, err := rotatedFiles(dir, name); err == nil && len(files) > 0 {
+			last := files[len(files)-1].Path
+			if err := setAppendOnly(last, true); err != nil {
+				internalLog(zapcore.WarnLevel, "Failed set log file append-only", String("file", last), Err(err))
+			}
+		}
+	}
+	notifyRotation()
+}
+
+func (f rollingFile) Write(p []byte) (int, error) {
+	// lumberjack renames the logfile to rotate it, which append-only forbids
+	rotating := f.size != nil && f.size.Load()+int64(len(p)) > f.maxSize()
+	if rotating && f.appendOnly() {
+		setAppendOnly(f.Filename, false)
+	}
+	n, err := f.Logger.Write(p)
+	if f.size == nil || err != nil {
+		if rotating && f.appendOnly() {
+			setAppendOnly(f.Filename, true)
+		}
+		return n, err
+	}
+
+	if f.size.Add(int64(len(p))) > f.maxSize() {
+		f.size.Store(int64(len(p)))
+		f.rotated()
+	}
+	return n, err
+}
+
+// Rotate rotates the logfile now
+func (f rollingFile) Rotate() error {
+	if f.appendOnly() {
+		setAppendOnly(f.Filename, false)
+	}
+	err := f.Logger.Rotate()
+	if err != nil {
+		f.newLogfile(false)
+		return err
+	}
+	f.size.Store(0)
+	f.rotated()
+	return nil
+}
+
+func (f rollingFile) Sync() error {
+	file, err := os.Open(f.Filename)
+	if os.IsNotExist(err) {
+		// nothing written yet
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
+
+// fsyncCore syncs file after the entries selected by config
+type fsyncCore struct {
+	zapcore.Core
+	file    zapcore.WriteSyncer
+	config  FsyncConfig
+	entries *atomic.Int64
+}
+
+var (
+	fsyncMu   sync.Mutex
+	fsyncStop chan struct{}
+)
+
+// startFsync syncs file every interval, replacing the previous ticker
+func startFsync(file zapcore.WriteSyncer, interval time.Duration) {
+	fsyncMu.Lock()
+	defer fsyncMu.Unlock()
+
+	if fsyncStop != nil {
+		close(fsyncStop)
+		fsyncStop = nil
+	}
+	if file == nil || interval <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	fsyncStop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := file.Sync(); err != nil {
+					internalLog(zapcore.ErrorLevel, "Failed sync log file", Err(err))
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func newFsyncCore(core zapcore.Core, file zapcore.WriteSyncer, config FsyncConfig) zapcore.Core {
+	return &fsyncCore{Core: core, file: file, config: config, entries: new(atomic.Int64)}
+}
+
+func (c *fsyncCore) With(fields []zapcore.Field) zapcore.Core {
+	return &fsyncCore{Core: c.Core.With(fields), file: c.file, config: c.config, entries: c.entries}
+}
+
+func (c *fsyncCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *fsyncCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if err := c.Core.Write(ent, fields); err != nil {
+		return err
+	}
+
+	sync := c.config.OnError && levelAtLeast(ent.Level, zapcore.ErrorLevel)
+	if c.config.Entries > 0 && c.entries.Add(1)%int64(c.config.Entries) == 0 {
+		sync = true
+	}
+	if sync {
+		return c.file.Sync()
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package logger
+
+import (
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// FuncField is the key of the function added by Config.CallerFunc
+const FuncField = "func"
+
+// shortFuncName drops the package path, github.com/org/app/store.(*DB).Get
+// becomes store.(*DB).Get
+func shortFuncName(name string) string {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// callerFuncProcess adds the function of the call site
+func callerFuncProcess(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+	if !ent.Caller.Defined || ent.Caller.Function == "" {
+		return ent, fields, true
+	}
+	return ent, append(fields, String(FuncField, shortFuncName(ent.Caller.Function))), true
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Keys of the concurrency fields
+const (
+	GoroutineIDField = "goroutine_id"
+	WorkerIDField    = "worker_id"
+)
+
+type workerKey struct{}
+
+// WithWorkerID returns a copy of ctx carrying a worker ID, logged by
+// Log.Ctx as worker_id. It is free, unlike Config.GoroutineID, and
+// survives the worker moving to another goroutine:
+//
+//	ctx := logger.WithWorkerID(ctx, "indexer-"+strconv.Itoa(i))
+func WithWorkerID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, workerKey{}, id)
+}
+
+// WorkerID returns the worker ID carried by ctx, empty when there is none
+func WorkerID(ctx context.Context) string {
+	id, _ := ctx.Value(workerKey{}).(string)
+	return id
+}
+
+type goroutineKey struct{}
+
+// WithGoroutineID returns a copy of ctx carrying the ID of the calling
+// goroutine, logged by Log.Ctx as goroutine_id. The ID is read once here
+// so Config.GoroutineID does not read it again for every entry, ctx must
+// then only be used on this goroutine:
+//
+//	ctx = logger.WithGoroutineID(ctx)
+//	for job := range jobs {
+//		log.Ctx(ctx).Info("Job done", logger.String("job", job.ID))
+//	}
+func WithGoroutineID(ctx context.Context) context.Context {
+	return context.WithValue(ctx, goroutineKey{}, goroutineID())
+}
+
+// ctxGoroutineID returns the goroutine ID stored by WithGoroutineID
+func ctxGoroutineID(ctx context.Context) (uint64, bool) {
+	id, ok := ctx.Value(goroutineKey{}).(uint64)
+	return id, ok
+}
+
+// stackBuffers hold the goroutine header, "goroutine 42 [running]:"
+var stackBuffers = sync.Pool{New: func() interface{} {
+	b := make([]byte, 64)
+	return &b
+}}
+
+// goroutineID parses the ID of the calling goroutine from its stack
+// header, 0 when it can not be read. runtime.Stack stops the goroutine to
+// format its frames, about a microsecond, see BenchmarkGoroutineID.
+func goroutineID() uint64 {
+	bp := stackBuffers.Get().(*[]byte)
+	defer stackBuffers.Put(bp)
+	b := *bp
+	b = b[:runtime.Stack(b, false)]
+
+	const prefix = "goroutine "
+	if len(b) <= len(prefix) || string(b[:len(prefix)]) != prefix {
+		return 0
+	}
+	var id uint64
+	for _, c := range b[len(prefix):] {
+		if c < '0' || c > '9' {
+			break
+		}
+		id = id*10 + uint64(c-'0')
+	}
+	return id
+}
+
+// goroutineProcess adds the goroutine ID to the entries without a worker
+// ID, it must run on the goroutine logging, above the async queue. The
+// stack is not parsed when the scope already has a worker_id or
+// goroutine_id field, from Log.Ctx with WithWorkerID or WithGoroutineID,
+// or from With.
+func goroutineProcess(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+	for _, key := range []string{WorkerIDField, GoroutineIDField} {
+		if hasField(key, context) || hasField(key, fields) {
+			return ent, fields, true
+		}
+	}
+	if ctx := entryContext(context, fields); ctx != nil && WorkerID(ctx) != "" {
+		return ent, fields, true
+	}
+	return ent, append(fields, zapcore.Field{Key: GoroutineIDField, Type: zapcore.Uint64Type, Integer: int64(goroutineID())}), true
+}
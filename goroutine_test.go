@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func BenchmarkGoroutineID(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		goroutineID()
+	}
+}
+
+func TestGoroutineProcessSkipsScopedIDs(t *testing.T) {
+	ctx := WithGoroutineID(context.Background())
+	id, _ := ctxGoroutineID(ctx)
+	if id == 0 || id != goroutineID() {
+		t.Fatalf("cached goroutine ID %d, want %d", id, goroutineID())
+	}
+
+	scopes := map[string][]zapcore.Field{
+		"WithGoroutineID": contextFields(ctx),
+		"WithWorkerID":    contextFields(WithWorkerID(context.Background(), "indexer-1")),
+		"With":            {String(WorkerIDField, "indexer-1")},
+	}
+	for name, scope := range scopes {
+		_, fields, _ := goroutineProcess(zapcore.Entry{}, scope, nil)
+		if len(fields) != 0 {
+			t.Errorf("%s: goroutine ID added again, %v", name, fields)
+		}
+	}
+
+	_, fields, _ := goroutineProcess(zapcore.Entry{}, nil, nil)
+	if len(fields) != 1 || uint64(fields[0].Integer) != goroutineID() {
+		t.Errorf("fields %v, want the goroutine ID", fields)
+	}
+}
@@ -0,0 +1,221 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Incident providers
+const (
+	IncidentPagerDuty = "pagerduty"
+	IncidentOpsgenie  = "opsgenie"
+)
+
+// IncidentConfig configures the incident sink, it triggers an incident for
+// the panic and fatal entries, deduplicated by a hash of the logger and
+// message so a crash loop pages once, with the fields as details. The
+// incidents are resolved by Shutdown when the process exits cleanly, a
+// fatal entry exits the process before that, a Configure keeps them open.
+type IncidentConfig struct {
+	// Provider is IncidentPagerDuty or IncidentOpsgenie
+	Provider string
+	// Key is the PagerDuty integration (routing) key or the Opsgenie API key
+	Key string
+	// Source names the process in the incident, default the host name
+	Source string
+	// URL overrides the provider endpoint, e.g. the Opsgenie EU instance
+	// https://api.eu.opsgenie.com
+	URL string
+	// Timeout of a call, default 5s, the process waits for it on fatal
+	Timeout time.Duration
+}
+
+type incidentWriter struct {
+	config IncidentConfig
+	client *http.Client
+}
+
+// openIncidents holds the dedup keys triggered per provider and key, it
+// outlives the writers so Shutdown also resolves the incidents triggered
+// before a Configure
+var openIncidents = struct {
+	mu   sync.Mutex
+	keys map[string]map[string]bool
+}{keys: make(map[string]map[string]bool)}
+
+func (w *incidentWriter) openKey() string {
+	return w.config.Provider + "\x00" + w.config.Key
+}
+
+func newIncidentWriter(config IncidentConfig) (*incidentWriter, error) {
+	switch config.Provider {
+	case IncidentPagerDuty:
+		if config.URL == "" {
+			config.URL = "https://events.pagerduty.com"
+		}
+	case IncidentOpsgenie:
+		if config.URL == "" {
+			config.URL = "https://api.opsgenie.com"
+		}
+	default:
+		return nil, errors.New("Bad incident provider " + config.Provider)
+	}
+	if config.Source == "" {
+		config.Source, _ = os.Hostname()
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	return &incidentWriter{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+// dedupKey hashes the logger and message, not the fields which usually
+// differ between two occurrences
+func dedupKey(ent zapcore.Entry) string {
+	sum := sha256.Sum256([]byte(ent.LoggerName + "\x00" + ent.Message))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (w *incidentWriter) WriteEntry(ent zapcore.Entry, fields []zapcore.Field, encoded []byte) error {
+	key := dedupKey(ent)
+	details := fieldMap(fields)
+	details["level"] = levelName(ent.Level)
+	if ent.LoggerName != "" {
+		details["logger"] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		details["caller"] = ent.Caller.TrimmedPath()
+	}
+	if ent.Stack != "" {
+		details["stacktrace"] = ent.Stack
+	}
+
+	var err error
+	if w.config.Provider == IncidentPagerDuty {
+		err = w.post("/v2/enqueue", map[string]interface{}{
+			"routing_key":  w.config.Key,
+			"event_action": "trigger",
+			"dedup_key":    key,
+			"payload": map[string]interface{}{
+				"summary":        ent.Message,
+				"source":         w.config.Source,
+				"severity":       "critical",
+				"timestamp":      ent.Time.UTC().Format(time.RFC3339Nano),
+				"component":      filepath.Base(os.Args[0]),
+				"custom_details": details,
+			},
+		})
+	} else {
+		// Opsgenie details only hold strings
+		strs := make(map[string]string, len(details))
+		for k, v := range details {
+			strs[k] = journalValue(v)
+		}
+		err = w.post("/v2/alerts", map[string]interface{}{
+			"message":  truncate(ent.Message, 130),
+			"alias":    key,
+			"source":   w.config.Source,
+			"priority": "P1",
+			"details":  strs,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	openIncidents.mu.Lock()
+	if openIncidents.keys[w.openKey()] == nil {
+		openIncidents.keys[w.openKey()] = make(map[string]bool)
+	}
+	openIncidents.keys[w.openKey()][key] = true
+	openIncidents.mu.Unlock()
+	return nil
+}
+
+func (w *incidentWriter) post(path string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, w.config.URL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.config.Provider == IncidentOpsgenie {
+		req.Header.Set("Authorization", "GenieKey "+w.config.Key)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("Incident %s status %s", w.config.Provider, resp.Status)
+	}
+	return nil
+}
+
+func (w *incidentWriter) resolve(key string) error {
+	if w.config.Provider == IncidentPagerDuty {
+		return w.post("/v2/enqueue", map[string]interface{}{
+			"routing_key":  w.config.Key,
+			"event_action": "resolve",
+			"dedup_key":    key,
+		})
+	}
+	return w.post("/v2/alerts/"+url.PathEscape(key)+"/close?identifierType=alias", map[string]interface{}{
+		"source": w.config.Source,
+		"note":   "Process shut down cleanly",
+	})
+}
+
+func (w *incidentWriter) Sync() error {
+	return nil
+}
+
+func (w *incidentWriter) Close() error {
+	return nil
+}
+
+// Shutdown resolves the incidents triggered by this process
+func (w *incidentWriter) Shutdown() error {
+	openIncidents.mu.Lock()
+	keys := openIncidents.keys[w.openKey()]
+	delete(openIncidents.keys, w.openKey())
+	openIncidents.mu.Unlock()
+
+	var failed error
+	for key := range keys {
+		if err := w.resolve(key); err != nil {
+			failed = err
+		}
+	}
+	return failed
+}
+
+// truncate cuts s to at most n bytes on a rune boundary
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
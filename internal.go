@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// InternalLoggerName is the named logger of the package's own events:
+// sink failures and reconnects, file rotation, queue pressure. Its level is
+// set like any named logger:
+//
+//	logger.SetNamedLevel(logger.InternalLoggerName, "warn")
+const InternalLoggerName = "logger.internal"
+
+type internalEvent struct {
+	level  zapcore.Level
+	msg    string
+	fields []zapcore.Field
+}
+
+var (
+	// internalEvents decouples the events from the sink reporting them, a
+	// sink logging about itself would otherwise write into itself
+	internalEvents = make(chan internalEvent, 256)
+	internalOnce   sync.Once
+)
+
+// internalLog queues an event for the internal logger, events are dropped
+// while the queue is full
+func internalLog(level zapcore.Level, msg string, fields ...zapcore.Field) {
+	internalOnce.Do(func() {
+		go func() {
+			for ev := range internalEvents {
+				writeInternal(ev)
+			}
+		}()
+	})
+
+	select {
+	case internalEvents <- internalEvent{level: level, msg: msg, fields: fields}:
+	default:
+	}
+}
+
+func writeInternal(ev internalEvent) {
+	if currentZap() == nil {
+		fmt.Fprintf(os.Stderr, "%s %s %v\n", levelName(ev.level), ev.msg, fieldMap(ev.fields))
+		return
+	}
+	Named(InternalLoggerName).Log(ev.level, ev.msg, ev.fields...)
+}
+
+// flushInternal writes the queued events in the caller, Configure uses it
+// so its own problems show up before it returns
+func flushInternal() {
+	for {
+		select {
+		case ev := <-internalEvents:
+			writeInternal(ev)
+		default:
+			return
+		}
+	}
+}
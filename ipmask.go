@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"net"
+	"net/netip"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// IPTruncationConfig truncates the IP address fields to their network,
+// a k-anonymity measure: 203.0.113.42 is logged as 203.0.113.0. The fields
+// built with IP and the keys passed to RegisterIPFields are IP addresses,
+// "host:port" values keep their port, values not parsing as an address are
+// left as is.
+type IPTruncationConfig struct {
+	// IPv4Bits is the IPv4 prefix kept, default 24
+	IPv4Bits int
+	// IPv6Bits is the IPv6 prefix kept, default 48
+	IPv6Bits int
+}
+
+func (c IPTruncationConfig) withDefaults() IPTruncationConfig {
+	if c.IPv4Bits <= 0 {
+		c.IPv4Bits = 24
+	}
+	if c.IPv6Bits <= 0 {
+		c.IPv6Bits = 48
+	}
+	return c
+}
+
+// ipMarker tags the fields built with IP, encoders ignore the Interface
+// of string fields
+type ipMarker struct{}
+
+// IP is a string field holding an IP address, truncated when
+// Config.IPTruncation is set
+func IP(key string, ip net.IP) zapcore.Field {
+	return zapcore.Field{Key: key, Type: zapcore.StringType, String: ip.String(), Interface: ipMarker{}}
+}
+
+var ipFields sync.Map // string -> struct{}
+
+// RegisterIPFields declares the string fields with these keys as IP
+// addresses, e.g. RegisterIPFields("client_ip", "remote_addr")
+func RegisterIPFields(keys ...string) {
+	for _, key := range keys {
+		ipFields.Store(key, struct{}{})
+	}
+}
+
+func isIPField(f zapcore.Field) bool {
+	if f.Type != zapcore.StringType {
+		return false
+	}
+	if _, ok := f.Interface.(ipMarker); ok {
+		return true
+	}
+	_, ok := ipFields.Load(f.Key)
+	return ok
+}
+
+// truncateIP keeps the network of addr, ok is false when it is not an
+// address
+func truncateIP(addr string, config IPTruncationConfig) (string, bool) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, ""
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return addr, false
+	}
+	ip = ip.Unmap()
+	bits := config.IPv6Bits
+	if ip.Is4() {
+		bits = config.IPv4Bits
+	}
+	prefix, err := ip.Prefix(bits)
+	if err != nil {
+		return addr, false
+	}
+	masked := prefix.Addr().WithZone("").String()
+	if port != "" {
+		return net.JoinHostPort(masked, port), true
+	}
+	return masked, true
+}
+
+func newIPTruncateProcess(config IPTruncationConfig) processFunc {
+	config = config.withDefaults()
+	return func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+		all := make([]zapcore.Field, 0, len(context)+len(fields))
+		all = append(append(all, context...), fields...)
+		for i := range all {
+			if isIPField(all[i]) {
+				all[i].String, _ = truncateIP(all[i].String, config)
+			}
+		}
+		return ent, all, true
+	}
+}
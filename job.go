@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Job outcomes, logged as "outcome"
+const (
+	JobSuccess = "success"
+	JobFailure = "failure"
+	JobPanic   = "panic"
+)
+
+// JobLog logs the runs of a job with standard entries: "Job started",
+// then "Job finished" or "Job failed" with the elapsed time and outcome.
+// Every entry carries the job name and a run_id, also set as the
+// correlation ID of the run context.
+type JobLog struct {
+	name string
+	log  *Log
+}
+
+// Job returns the JobLog of the job name, logging to Named("job"):
+//
+//	c.AddFunc("@hourly", logger.Job("compact").Func(compact))
+func Job(name string) *JobLog {
+	return &JobLog{name: name, log: Named("job")}
+}
+
+// Run runs fn and logs it, a panic of fn is recovered, logged with its
+// stack and returned as an error
+func (j *JobLog) Run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	id := NewCorrelationID()
+	ctx = WithCorrelationID(ctx, id)
+	log := j.log.Ctx(ctx)
+	fields := []zapcore.Field{String("job", j.name), String("run_id", id)}
+
+	log.Info("Job started", fields...)
+	start := time.Now()
+	defer func() {
+		fields = append(fields, Duration("elapsed", time.Since(start)))
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Job %s panic: %v", j.name, r)
+			log.Error("Job failed", append(fields, String("outcome", JobPanic), zap.Any("panic", r), zap.Stack("stacktrace"))...)
+			return
+		}
+		if err != nil {
+			log.Error("Job failed", append(fields, String("outcome", JobFailure), Err(err))...)
+			return
+		}
+		log.Info("Job finished", append(fields, String("outcome", JobSuccess))...)
+	}()
+	return fn(ctx)
+}
+
+// Func returns a func running fn through Run with a background context,
+// for schedulers taking a func()
+func (j *JobLog) Func(fn func(ctx context.Context) error) func() {
+	return func() {
+		j.Run(context.Background(), fn)
+	}
+}
+
+// CronLogger adapts log to the cron.Logger of robfig/cron, the key and
+// value pairs become fields and the chatty info messages are logged at the
+// debug level:
+//
+//	c := cron.New(cron.WithLogger(logger.CronLogger(logger.Named("cron"))))
+func CronLogger(log *Log) cron.Logger {
+	return cronLogger{log: log}
+}
+
+type cronLogger struct {
+	log *Log
+}
+
+func (c cronLogger) Info(msg string, keysAndValues ...interface{}) {
+	c.log.Debug(msg, pairFields(keysAndValues)...)
+}
+
+func (c cronLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	c.log.Error(msg, append(pairFields(keysAndValues), Err(err))...)
+}
+
+// pairFields turns alternating keys and values into fields, a dangling
+// key is logged with a nil value
+func pairFields(kv []interface{}) []zapcore.Field {
+	fields := make([]zapcore.Field, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		var v interface{}
+		if i+1 < len(kv) {
+			v = kv[i+1]
+		}
+		fields = append(fields, zap.Any(fmt.Sprint(kv[i]), v))
+	}
+	return fields
+}
@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultJournalSocket is where journald reads native protocol datagrams
+const defaultJournalSocket = "/run/systemd/journal/socket"
+
+// JournaldConfig configures the journald sink, entries are sent with the
+// native protocol: the message as MESSAGE, the level as PRIORITY and every
+// field as a journal field, its key uppercased, so journalctl can filter on
+// them, e.g. journalctl USER_ID=42
+type JournaldConfig struct {
+	// Socket is the journal socket, default /run/systemd/journal/socket
+	Socket string
+	// Identifier is the SYSLOG_IDENTIFIER, default the program name
+	Identifier string
+}
+
+type journaldWriter struct {
+	mu     sync.Mutex
+	config JournaldConfig
+	conn   *net.UnixConn
+}
+
+func newJournaldWriter(config JournaldConfig) (*journaldWriter, error) {
+	if config.Socket == "" {
+		config.Socket = defaultJournalSocket
+	}
+	if config.Identifier == "" {
+		config.Identifier = filepath.Base(os.Args[0])
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: config.Socket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journaldWriter{config: config, conn: conn}, nil
+}
+
+// journalPriority maps the levels to the syslog priorities, panic and
+// fatal stay critical, emergency would be broadcast to every terminal
+func journalPriority(level zapcore.Level) int {
+	switch rank := levelRank(level); {
+	case rank < levelRank(zapcore.InfoLevel):
+		return 7
+	case rank < levelRank(NoticeLevel):
+		return 6
+	case rank < levelRank(zapcore.WarnLevel):
+		return 5
+	case rank < levelRank(zapcore.ErrorLevel):
+		return 4
+	case rank < levelRank(CriticalLevel):
+		return 3
+	}
+	return 2
+}
+
+// journalKey turns a field key into a journal field name, uppercase
+// letters, digits and underscores not starting with an underscore
+func journalKey(key string) string {
+	b := []byte(strings.ToUpper(key))
+	for i, c := range b {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			b[i] = '_'
+		}
+	}
+	name := strings.TrimLeft(string(b), "_")
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "F_" + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// appendJournalField writes key=value, or the length prefixed form for
+// values holding newlines
+func appendJournalField(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.Write(size[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+func journalValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err == nil {
+			return string(data)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+func (w *journaldWriter) WriteEntry(ent zapcore.Entry, fields []zapcore.Field, encoded []byte) error {
+	var buf bytes.Buffer
+	appendJournalField(&buf, "MESSAGE", ent.Message)
+	appendJournalField(&buf, "PRIORITY", strconv.Itoa(journalPriority(ent.Level)))
+	appendJournalField(&buf, "SYSLOG_IDENTIFIER", w.config.Identifier)
+	appendJournalField(&buf, "LEVEL", levelName(ent.Level))
+	if ent.LoggerName != "" {
+		appendJournalField(&buf, "LOGGER", ent.LoggerName)
+	}
+	if ent.Caller.Defined {
+		appendJournalField(&buf, "CODE_FILE", ent.Caller.File)
+		appendJournalField(&buf, "CODE_LINE", strconv.Itoa(ent.Caller.Line))
+		if ent.Caller.Function != "" {
+			appendJournalField(&buf, "CODE_FUNC", ent.Caller.Function)
+		}
+	}
+	if ent.Stack != "" {
+		appendJournalField(&buf, "STACKTRACE", ent.Stack)
+	}
+	for key, value := range fieldMap(fields) {
+		appendJournalField(&buf, journalKey(key), journalValue(value))
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := w.conn.Write(buf.Bytes())
+	return err
+}
+
+func (w *journaldWriter) Sync() error {
+	return nil
+}
+
+func (w *journaldWriter) Close() error {
+	return w.conn.Close()
+}
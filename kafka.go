@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// StdLogger logs the Print calls of client libraries at a fixed level, it
+// satisfies sarama.StdLogger:
+//
+//	sarama.Logger = logger.SaramaLogger(logger.Named("sarama"), zapcore.DebugLevel)
+type StdLogger struct {
+	log   *Log
+	level zapcore.Level
+}
+
+// SaramaLogger returns a sarama.StdLogger writing to log at level
+func SaramaLogger(log *Log, level zapcore.Level) *StdLogger {
+	return &StdLogger{log: log, level: level}
+}
+
+func (s *StdLogger) Print(v ...interface{}) {
+	s.write(fmt.Sprint(v...))
+}
+
+func (s *StdLogger) Printf(format string, v ...interface{}) {
+	s.write(fmt.Sprintf(format, v...))
+}
+
+func (s *StdLogger) Println(v ...interface{}) {
+	s.write(fmt.Sprintln(v...))
+}
+
+// write drops the trailing newline and the "[sarama] " prefix, the logger
+// name already tells the source
+func (s *StdLogger) write(msg string) {
+	msg = strings.TrimPrefix(strings.TrimRight(msg, "\n"), "[sarama] ")
+	s.log.Log(s.level, msg)
+}
+
+// KafkaGoLogger returns a Printf style function writing to log at level,
+// for the Logger and ErrorLogger of the kafka-go reader and writer:
+//
+//	kafka.ReaderConfig{
+//		Logger:      kafka.LoggerFunc(logger.KafkaGoLogger(log, zapcore.DebugLevel)),
+//		ErrorLogger: kafka.LoggerFunc(logger.KafkaGoLogger(log, zapcore.ErrorLevel)),
+//	}
+func KafkaGoLogger(log *Log, level zapcore.Level) func(string, ...interface{}) {
+	return func(format string, v ...interface{}) {
+		log.Log(level, strings.TrimRight(fmt.Sprintf(format, v...), "\n"))
+	}
+}
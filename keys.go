@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// KeyCase values
+const (
+	KeyCaseSnake = "snake"
+)
+
+// Duplicate key policies
+const (
+	// DuplicateLastWins keeps the last field of a key, call site fields override context
+	DuplicateLastWins = "last"
+	// DuplicateError keeps the first field of a key and lists the dropped keys in "key_collisions"
+	DuplicateError = "error"
+	// DuplicateSuffix renames later fields of a key to key_2, key_3...
+	DuplicateSuffix = "suffix"
+)
+
+var snakeKeys sync.Map // string -> string
+
+// snakeCase converts camelCase, PascalCase, kebab-case and spaced keys,
+// acronyms are kept together: HTTPStatus becomes http_status
+func snakeCase(key string) string {
+	if v, ok := snakeKeys.Load(key); ok {
+		return v.(string)
+	}
+
+	runes := []rune(key)
+	var b strings.Builder
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ':
+			b.WriteByte('_')
+		case unicode.IsUpper(r):
+			if i > 0 && runes[i-1] != '_' && runes[i-1] != '-' && runes[i-1] != ' ' &&
+				(unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+					i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	snake := b.String()
+	snakeKeys.Store(key, snake)
+	return snake
+}
+
+func newKeyNormalizer(keyCase, prefix, duplicates string) processFunc {
+	return func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+		all := make([]zapcore.Field, 0, len(context)+len(fields)+1)
+		all = append(append(all, context...), fields...)
+		for i := range all {
+			key := all[i].Key
+			if keyCase == KeyCaseSnake {
+				key = snakeCase(key)
+			}
+			all[i].Key = prefix + key
+		}
+		return ent, dedupeFields(all, duplicates), true
+	}
+}
+
+// dedupeFields applies the duplicate policy, every namespace is a scope of its own
+func dedupeFields(fields []zapcore.Field, policy string) []zapcore.Field {
+	switch policy {
+	case DuplicateLastWins, DuplicateError, DuplicateSuffix:
+	default:
+		return fields
+	}
+
+	out := fields[:0]
+	seen := make(map[string]int, len(fields))
+	var collisions []string
+	for _, f := range fields {
+		if f.Type == zapcore.NamespaceType {
+			out = append(out, f)
+			seen = make(map[string]int)
+			continue
+		}
+		if f.Type == zapcore.SkipType {
+			continue
+		}
+
+		idx, dup := seen[f.Key]
+		if !dup {
+			seen[f.Key] = len(out)
+			out = append(out, f)
+			continue
+		}
+		switch policy {
+		case DuplicateLastWins:
+			out[idx] = f
+		case DuplicateError:
+			collisions = append(collisions, f.Key)
+		case DuplicateSuffix:
+			for n := 2; ; n++ {
+				key := f.Key + "_" + strconv.Itoa(n)
+				if _, taken := seen[key]; !taken {
+					seen[key] = len(out)
+					f.Key = key
+					out = append(out, f)
+					break
+				}
+			}
+		}
+	}
+	if len(collisions) > 0 {
+		out = append(out, Strings("key_collisions", collisions))
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ld "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+)
+
+// LaunchDarklyLevels is a LevelProvider reading a LaunchDarkly flag whose
+// value is a level name or a RemoteSettings JSON document. The flag is
+// evaluated for context, percentage rollouts and per service targeting
+// are set up on the flag:
+//
+//	context := ldcontext.NewBuilder(hostname).Kind("service").SetString("service", "billing").Build()
+//	c := logger.WatchLevelProvider(logger.NewLaunchDarklyLevels(client, "log-level", context), 0)
+type LaunchDarklyLevels struct {
+	client  *ld.LDClient
+	flag    string
+	context ldcontext.Context
+	events  <-chan interfaces.FlagValueChangeEvent
+	changes chan struct{}
+}
+
+// NewLaunchDarklyLevels subscribes to the changes of flag, Close unsubscribes
+func NewLaunchDarklyLevels(client *ld.LDClient, flag string, context ldcontext.Context) *LaunchDarklyLevels {
+	p := &LaunchDarklyLevels{
+		client:  client,
+		flag:    flag,
+		context: context,
+		changes: make(chan struct{}, 1),
+	}
+	p.events = client.GetFlagTracker().AddFlagValueChangeListener(flag, context, ldvalue.Null())
+	go func() {
+		for range p.events {
+			select {
+			case p.changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return p
+}
+
+func (p *LaunchDarklyLevels) Settings() (*RemoteSettings, error) {
+	value, err := p.client.JSONVariation(p.flag, p.context, ldvalue.Null())
+	if err != nil || value.IsNull() {
+		return nil, err
+	}
+	if value.Type() == ldvalue.StringType {
+		return flagSettings(value.StringValue())
+	}
+	return flagSettings(value.JSONString())
+}
+
+func (p *LaunchDarklyLevels) Changes() <-chan struct{} {
+	return p.changes
+}
+
+// Close stops listening to the flag changes
+func (p *LaunchDarklyLevels) Close() error {
+	p.client.GetFlagTracker().RemoveFlagValueChangeListener(p.events)
+	return nil
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var colorNames = map[string]string{
+	"black":   "\x1b[30m",
+	"red":     colorRed,
+	"green":   colorGreen,
+	"yellow":  colorYellow,
+	"blue":    colorBlue,
+	"magenta": colorMagenta,
+	"cyan":    colorCyan,
+	"white":   "\x1b[37m",
+	"gray":    colorGray,
+	"bold":    colorBold,
+}
+
+// colorCode resolves a color name, raw escape sequences are used as is
+func colorCode(color string) string {
+	if strings.HasPrefix(color, "\x1b[") {
+		return color
+	}
+	return colorNames[strings.ToLower(color)]
+}
+
+// levelLabel is the custom label of level, zap's lowercase name by default
+func levelLabel(config Config, level zapcore.Level) string {
+	if label, ok := config.LevelLabels[level]; ok {
+		return label
+	}
+	if config.Datadog != nil {
+		if label, ok := datadogStatus[level]; ok {
+			return label
+		}
+	}
+	return levelName(level)
+}
+
+// levelEncoder writes the custom labels, colored only for the console encoding
+func levelEncoder(config Config, color bool) zapcore.LevelEncoder {
+	return func(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+		label := levelLabel(config, level)
+		if code := colorCode(config.LevelColors[level]); color && code != "" && !config.DisableColor {
+			label = code + label + colorReset
+		}
+		enc.AppendString(label)
+	}
+}
@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"runtime"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var processStart = time.Now()
+
+// hostFields describes the host and the process
+func hostFields() []zapcore.Field {
+	hostname, _ := os.Hostname()
+	return []zapcore.Field{
+		String("host", hostname),
+		Int("pid", os.Getpid()),
+		String("os", runtime.GOOS),
+		String("arch", runtime.GOARCH),
+		Int("num_cpu", runtime.NumCPU()),
+	}
+}
+
+// configHash is a short sha256 of the JSON form of config
+func configHash(config interface{}) string {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// LogStartup logs the standardized "startup" event with the config summary
+// and its hash, the command line flags, host facts and build information:
+//
+//	logger.LogStartup(map[string]interface{}{"listen": addr, "workers": n})
+func LogStartup(cfgSummary interface{}) {
+	fields := []zapcore.Field{
+		zap.Any("config", cfgSummary),
+		String("config_hash", configHash(cfgSummary)),
+		Strings("flags", os.Args[1:]),
+	}
+	fields = append(fields, hostFields()...)
+	fields = append(fields, BuildInfoFields()...)
+
+	// called without a Log method in between, the base caller skip
+	// reports the caller of LogStartup
+	currentZap().Info("startup", eventFields("startup", fields)...)
+}
+
+// LogShutdown logs the standardized "shutdown" event, a zero uptime is
+// replaced by the time since the process started
+func LogShutdown(reason string, uptime time.Duration) {
+	if uptime <= 0 {
+		uptime = time.Since(processStart)
+	}
+	fields := []zapcore.Field{
+		String("reason", reason),
+		Duration("uptime", uptime),
+	}
+	fields = append(fields, hostFields()...)
+
+	// called without a Log method in between, the base caller skip
+	// reports the caller of LogShutdown
+	currentZap().Info("shutdown", eventFields("shutdown", fields)...)
+	currentZap().Sync()
+}
@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLifecycleCaller(t *testing.T) {
+	old := state.Load()
+	obs, logs := observer.New(zapcore.DebugLevel)
+	state.Store(&loggerState{zap: zap.New(obs, zap.AddCaller(), zap.AddCallerSkip(1)), config: old.config})
+	t.Cleanup(func() { state.Store(old) })
+
+	LogStartup(map[string]interface{}{"workers": 1})
+	LogShutdown("test", 0)
+
+	for _, e := range logs.All() {
+		if file := filepath.Base(e.Caller.File); file != "lifecycle_test.go" {
+			t.Errorf("%s: caller %s, want lifecycle_test.go", e.Message, e.Caller)
+		}
+	}
+	if logs.Len() != 2 {
+		t.Errorf("%d entries logged, want 2", logs.Len())
+	}
+}
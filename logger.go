@@ -1,25 +1,61 @@
 package logger
 
 import (
+	"io"
+	"context"
 	"os"
 	"fmt"
-	"path"
 	"time"
 	"errors"
 	"runtime"
+	"encoding/json"
 	"path/filepath"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"github.com/natefinch/lumberjack"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+// Log is the logging handle, the zero value logs to DefaultZapLogger
+//
+// Info, Warn and Error with a plain message and a few typed fields
+// (String, Int, Bool, Duration, ...) do not allocate: disabled levels return
+// before any work, enabled ones reuse pooled entries and buffers as long as
+// the json or console encoding is used, Caller is off and no entry processor
+// (Async, Sampling, KeyCase, SchemaValidation, ...) is configured. Fields built from
+// arbitrary values (zap.Any, Fields, Marshal) allocate.
+//
+// BenchmarkInfo measures 0 allocs/op for zero to five such fields and
+// TestInfoAllocs fails once that changes.
 type Log struct {
+	// muted drops every entry, see When
+	muted bool
+	// name of the logger, see Named
+	name string
+	// ctx is attached to every entry, see Ctx
+	ctx context.Context
 }
 
 // Configuration for logging
 type Config struct {
 	// EncodeLogsAsJson makes the log framework log JSON
 	EncodeLogsAsJson bool
+	// Encoding selects the encoder by name (EncodingJSON, EncodingConsole,
+	// EncodingMsgpack, EncodingCBOR, EncodingProtobuf, EncodingCEF, EncodingLEEF,
+	// EncodingDev) and overrides EncodeLogsAsJson when set, LOG_PRETTY=1
+	// forces EncodingDev on stdout and console sinks, the logfile and the
+	// other sinks keep Encoding
+	Encoding string
+	// DisableColor turns off colors of the console and dev encodings, the dev
+	// encoding also honors NO_COLOR. Colors are only written to terminals.
+	DisableColor bool
+	// LevelLabels replaces the level names, e.g. {zapcore.WarnLevel: "WARNING"}
+	LevelLabels map[zapcore.Level]string
+	// LevelColors colors the level of the console and dev encodings by name
+	// ("red", "yellow", "green", "blue", "magenta", "cyan", "gray", ...)
+	LevelColors map[zapcore.Level]string
+	// DisableHTMLEscape keeps <, > and & verbatim in reflected and RawJSON values
+	DisableHTMLEscape bool
 	// FileLoggingEnabled makes the framework log to a file
 	// the fields below can be skipped if this value is false!
 	FileLoggingEnabled bool
@@ -33,10 +69,178 @@ type Config struct {
 	MaxBackups int
 	// MaxAge the max age in days to keep a logfile
 	MaxAge int
+	// Mmap writes the logfile through a memory mapping when not nil, experimental
+	Mmap *MmapConfig
+	// FileShards splits the logfile into this many files, app.0.log,
+	// app.1.log..., so concurrent goroutines do not wait on each other,
+	// ordering across files is lost
+	FileShards int
+	// Tenant splits the logfile by tenant when not nil
+	Tenant *TenantConfig
+	// Fsync flushes the logfile to disk by entry count, interval or level
+	// when not nil, trading throughput for durability
+	Fsync *FsyncConfig
 	// StackStrace make debug log stack
 	StackStrace bool
-	// LogLevel log level
+	// LogLevel is the root level, the zero value (info) keeps the level
+	// set by SetLogLevel or the previous Configure unless LevelSet is set
 	LogLevel zapcore.Level
+	// LevelSet applies LogLevel even when it is info
+	LevelSet bool
+	// Socket ships JSON entries to a tcp/udp/unix endpoint when not nil
+	Socket *SocketConfig
+	// NATS publishes JSON entries to a NATS subject when not nil
+	NATS *NATSConfig
+	// ClickHouse batches entries into a ClickHouse table when not nil
+	ClickHouse *ClickHouseConfig
+	// SQLite writes entries to a local SQLite database when not nil
+	SQLite *SQLiteConfig
+	// MQTT publishes JSON entries to an MQTT topic when not nil
+	MQTT *MQTTConfig
+	// EventLog writes entries to the Windows Event Log when not nil
+	EventLog *EventLogConfig
+	// CEF configures the CEF/LEEF encoders and the optional SIEM socket
+	CEF *CEFConfig
+	// Sinks lists further outputs, each with its own encoding and level
+	Sinks []SinkConfig
+	// MetricsRegisterer receives the prometheus metrics built from Counter
+	// and Gauge fields, the fields are only logged when nil
+	MetricsRegisterer prometheus.Registerer
+	// MetricsNamespace prefixes the metric names
+	MetricsNamespace string
+	// SchemaValidation checks every entry against the schema set by
+	// RegisterSchema, meant for development and staging
+	SchemaValidation bool
+	// SchemaViolationHandler is called with every violation, when nil the
+	// violations are only attached to the entry as "schema_violations"
+	SchemaViolationHandler func(SchemaViolation)
+	// KeyCase rewrites field keys, KeyCaseSnake turns userID into user_id
+	KeyCase string
+	// KeyPrefix is prepended to every field key
+	KeyPrefix string
+	// DuplicateKeys is the policy for fields sharing a key, DuplicateLastWins,
+	// DuplicateError or DuplicateSuffix, duplicates are emitted as is when empty
+	DuplicateKeys string
+	// Caller adds the file:line of the call site as "caller"
+	Caller bool
+	// Stacktrace attaches a stack trace to entries at or above this level
+	// when not nil
+	Stacktrace *zapcore.Level
+	// ComponentField adds a "component" field derived from the package of
+	// the call site, the last element of the package path by default
+	ComponentField bool
+	// ComponentNames renames packages for ComponentField, keys are package
+	// paths or path prefixes, the longest match wins
+	ComponentNames map[string]string
+	// BuildInfo stamps every entry with BuildInfoFields
+	BuildInfo bool
+	// RuntimeStatsInterval logs goroutine, heap, GC and open fd statistics
+	// at this interval, disabled when 0
+	RuntimeStatsInterval time.Duration
+	// RuntimeStatsLevel is the level of the runtime stats entries
+	RuntimeStatsLevel zapcore.Level
+	// CrashLoop enables crash loop detection when not nil
+	CrashLoop *CrashLoopConfig
+	// Sampling drops repeated entries when not nil, error and above are kept
+	Sampling *SamplingConfig
+	// Async queues entries and writes them from a background goroutine when
+	// not nil, entries overflowing the queue are dropped and reported
+	Async *AsyncConfig
+	// Engine is the async queue implementation, EngineRing or a channel by
+	// default, EngineRing enables the async queue even without Async
+	Engine string
+	// Filters drop or rewrite entries by message, fields, caller package or level
+	Filters []Filter
+	// SpanEvents adds the entries logged with Log.Ctx as events of the
+	// active OpenTelemetry span when not nil
+	SpanEvents *SpanEventConfig
+	// TraceURL adds a "trace_url" field to the error and above entries
+	// logged with Log.Ctx inside a span, {trace_id} and {span_id} are
+	// replaced, e.g. "https://tempo.example.com/trace/{trace_id}"
+	TraceURL string
+	// Baggage adds the OpenTelemetry baggage of the entries logged with
+	// Log.Ctx as fields when not nil
+	Baggage *BaggageConfig
+	// CorrelationIDFormat is the format of NewCorrelationID,
+	// CorrelationUUIDv7 (default), CorrelationULID or CorrelationKSUID
+	CorrelationIDFormat string
+	// RecentEntries keeps the last entries logged in memory for
+	// RecentEntries and BuildSupportBundle, disabled when 0
+	RecentEntries int
+	// BinaryEncoding is how Binary fields and string fields holding invalid
+	// UTF-8 are written, BinaryBase64, BinaryHex or BinaryReplace. Binary
+	// defaults to BinaryBase64, other fields are left to the encoder, the
+	// json one replaces the invalid bytes, when empty
+	BinaryEncoding string
+	// BinaryMaxSize cuts the data of Binary fields, default 4096 bytes
+	BinaryMaxSize int
+	// Multiline keeps entries on one line for line oriented collectors,
+	// MultilineEscape or MultilineLines, newlines are left to the encoder
+	// when empty
+	Multiline string
+	// StdioCapture redirects the stdout and stderr descriptors of the
+	// process into the logger when not nil, linux only
+	StdioCapture *StdioCaptureConfig
+	// DisableStdout stops writing the entries to stdout, for configurations
+	// shipping them through the logfile or the sinks only
+	DisableStdout bool
+	// DiskGuard degrades the logger when the log directory runs low on
+	// space, when not nil
+	DiskGuard *DiskGuardConfig
+	// Retention deletes the rotated logfiles by calendar days or count when
+	// not nil, MaxBackups and MaxAge are then ignored
+	Retention *RetentionConfig
+	// Manifest keeps a <Filename>.manifest.jsonl next to the logfile listing
+	// the size, sha256 and time range of every rotated file
+	Manifest bool
+	// Archive uploads the rotated logfiles when not nil
+	Archive *ArchiveConfig
+	// FileAttrs sets the owner and extended attributes of the log
+	// directory and logfiles when not nil
+	FileAttrs *FileAttrConfig
+	// AdaptiveSampling drops a growing share of the info and debug entries
+	// while the outputs fall behind, when not nil
+	AdaptiveSampling *AdaptiveSamplingConfig
+	// IPTruncation truncates the IP address fields to their network when
+	// not nil, see IP and RegisterIPFields
+	IPTruncation *IPTruncationConfig
+	// AllowedFields is the strict mode, when not empty only the fields
+	// with these keys are written, the others are dropped and counted, see
+	// DroppedFields. Capture sessions and RecentEntries see every field.
+	AllowedFields []string
+	// DualWrite also sends the entries to the logrus logger being
+	// replaced when not nil, see DualWriteStats
+	DualWrite *DualWriteConfig
+	// SchemaVersion stamps every entry with a schema_version field when
+	// above 0, to be bumped when fields are renamed or change meaning, see
+	// logreader.RegisterMigration
+	SchemaVersion int
+	// GoroutineID adds the goroutine_id field to the entries not logged
+	// with a WithWorkerID context. Reading it parses the goroutine stack,
+	// about a microsecond per entry, it is meant for debugging concurrency
+	// issues. WithGoroutineID reads it once per context instead.
+	GoroutineID bool
+	// CallerFunc adds the function of the call site as "func", e.g.
+	// store.(*DB).Get, next to the caller file:line
+	CallerFunc bool
+	// SourceLink adds a "source_url" field to the error and above entries
+	// when not nil
+	SourceLink *SourceLinkConfig
+	// SLO feeds the error and above entries, and the entries naming an
+	// SLO, to an SLO tracker when not nil
+	SLO *SLOConfig
+	// StatsD sends the entry counts and the metrics of the Counter and
+	// Gauge fields to a StatsD or DogStatsD server when not nil
+	StatsD *StatsDConfig
+	// Datadog writes the attributes of the Datadog log pipeline when not
+	// nil, see DatadogConfig
+	Datadog *DatadogConfig
+	// WideEvents sends the wide events of StartWideEvent to Honeycomb
+	// when not nil
+	WideEvents *HoneycombConfig
+	// MsgLocale selects the translations of the Msg templates, see
+	// RegisterMsgTranslation, the default templates when empty
+	MsgLocale string
 }
 
 // How to log, by example:
@@ -45,8 +249,12 @@ type Config struct {
 // logger.Error("It went wrong, zap.Stack())
 
 // DefaultZapLogger is the default logger instance that should be used to log
-// It's assigned a default value here for tests (which do not call log.Configure())
-var DefaultZapLogger = newZapLogger(false, os.Stdout)
+// Until Configure or Init it writes console entries to stderr, so logging
+// early (or in tests which do not call log.Configure()) does not panic
+//
+// Configure assigns it without synchronization, code reconfiguring while
+// other goroutines log should log through Log, which is safe.
+var DefaultZapLogger = newPreInitLogger()
 var DefaultLoggerConfig Config
 
 func Bool(name string, value bool) zapcore.Field {
@@ -61,6 +269,10 @@ func String(name string, value string) zapcore.Field {
 	return zap.String(name, value)
 }
 
+func Strings(name string, value []string) zapcore.Field {
+	return zap.Strings(name, value)
+}
+
 func Int64(name string, value int64) zapcore.Field {
 	return zap.Int64(name, value)
 }
@@ -73,17 +285,23 @@ func Err(err error) zapcore.Field {
 	return zap.Error(err)
 }
 
+// RawJSON embeds pre-encoded JSON as is, data must be valid JSON
+func RawJSON(name string, data []byte) zapcore.Field {
+	return zap.Reflect(name, json.RawMessage(data))
+}
+
 // Debug Log a message at the debug level. Messages include any context that's
 // accumulated on the logger, as well as any fields added at the log site.
 //
 // Use zap.String(key, value), zap.Int(key, value) to log fields. These fields
 // will be marshalled as JSON in the logfile and key value pairs in the console!
 func (l *Log) Debug(msg string, fields ...zapcore.Field) {
-	if DefaultLoggerConfig.StackStrace {
-		fields = append(fields, Stack())
-		DefaultZapLogger.Debug(msg, fields...)
-	} else {
-	  DefaultZapLogger.Debug(msg, fields...)
+	if ce := l.zap().Check(zapcore.DebugLevel, msg); ce != nil {
+		p := getFields(fields, 1)
+		if currentConfig().StackStrace {
+			*p = append(*p, Stack())
+		}
+		l.writePooled(ce, p)
 	}
 }
 
@@ -93,7 +311,9 @@ func (l *Log) Debug(msg string, fields ...zapcore.Field) {
 // Use zap.String(key, value), zap.Int(key, value) to log fields. These fields
 // will be marshalled as JSON in the logfile and key value pairs in the console!
 func (l *Log) Info(msg string, fields ...zapcore.Field) {
-	DefaultZapLogger.Info(msg, fields...)
+	if ce := l.zap().Check(zapcore.InfoLevel, msg); ce != nil {
+		l.write(ce, fields)
+	}
 }
 
 // Warn log a message at the warn level. Messages include any context that's
@@ -102,7 +322,9 @@ func (l *Log) Info(msg string, fields ...zapcore.Field) {
 // Use zap.String(key, value), zap.Int(key, value) to log fields. These fields
 // will be marshalled as JSON in the logfile and key value pairs in the console!
 func (l *Log) Warn(msg string, fields ...zapcore.Field) {
-	DefaultZapLogger.Warn(msg, fields...)
+	if ce := l.zap().Check(zapcore.WarnLevel, msg); ce != nil {
+		l.write(ce, fields)
+	}
 }
 
 // Error Log a message at the error level. Messages include any context that's
@@ -111,7 +333,9 @@ func (l *Log) Warn(msg string, fields ...zapcore.Field) {
 // Use zap.String(key, value), zap.Int(key, value) to log fields. These fields
 // will be marshalled as JSON in the logfile and key value pairs in the console!
 func (l *Log) Error(msg string, fields ...zapcore.Field) {
-	DefaultZapLogger.Error(msg, fields...)
+	if ce := l.zap().Check(zapcore.ErrorLevel, msg); ce != nil {
+		l.write(ce, fields)
+	}
 }
 
 // Panic Log a message at the Panic level. Messages include any context that's
@@ -120,7 +344,9 @@ func (l *Log) Error(msg string, fields ...zapcore.Field) {
 // Use zap.String(key, value), zap.Int(key, value) to log fields. These fields
 // will be marshalled as JSON in the logfile and key value pairs in the console!
 func (l *Log) Panic(msg string, fields ...zapcore.Field) {
-	DefaultZapLogger.Panic(msg, fields...)
+	if ce := l.zap().Check(zapcore.PanicLevel, msg); ce != nil {
+		l.write(ce, fields)
+	}
 }
 
 // Fatal Log a message at the fatal level. Messages include any context that's
@@ -129,7 +355,13 @@ func (l *Log) Panic(msg string, fields ...zapcore.Field) {
 // Use zap.String(key, value), zap.Int(key, value) to log fields. These fields
 // will be marshalled as JSON in the logfile and key value pairs in the console!
 func (l *Log) Fatal(msg string, fields ...zapcore.Field) {
-	DefaultZapLogger.Fatal(msg, fields...)
+	ce := l.zap().Check(zapcore.FatalLevel, msg)
+	if ce == nil {
+		return
+	}
+	p := getFields(fields, 0)
+	*p = append(*p, recordFatal()...)
+	l.writePooled(ce, p)
 }
 
 func Stack() zapcore.Field {
@@ -171,13 +403,111 @@ func Stack() zapcore.Field {
 // The output log file will be located at /var/log/auth-service/auth-service.log and
 // will be rolled when it reaches 20MB with a maximum of 1 backup.
 func Configure(config Config) {
-	writers := []zapcore.WriteSyncer{os.Stdout}
+	configureMu.Lock()
+	defer configureMu.Unlock()
+
+	if !validEncoding(config.encoding()) {
+		internalLog(zapcore.ErrorLevel, "Bad encoding, fallback to console", String("encoding", config.Encoding))
+		config.Encoding = EncodingConsole
+	}
+	crashLoopConfig.Store(nil)
+	// the zero level keeps the one set by SetLogLevel or the last Configure
+	level := config.LogLevel
+	if level == zapcore.InfoLevel && !config.LevelSet {
+		level = DefaultLoggerConfig.LogLevel
+	}
+	if crash := crashLoopLevel(config.CrashLoop, level); crash != level {
+		internalLog(zapcore.WarnLevel, "Crash loop detected", String("state_file", config.CrashLoop.StateFile), String("level", levelName(crash)))
+		level = crash
+	}
+	config.LogLevel = level
+	DefaultLoggerConfig.LogLevel = level
+	rootLevel.Store(int32(level))
+	configureStdio(config.StdioCapture)
+
+	// closers release the writers once the configuration is replaced
+	var closers []io.Closer
+	encoding := config.encoding()
+	var writers []zapcore.WriteSyncer
+	if !config.DisableStdout {
+		writers = append(writers, os.Stdout)
+		encoding = config.consoleEncoding()
+	}
+	var file zapcore.WriteSyncer
+	var tenants *tenantWriter
 	if config.FileLoggingEnabled {
-		writers = append(writers, newRollingFile(config))
+		file = newRollingFile(config)
+		if c, ok := file.(io.Closer); ok {
+			closers = append(closers, c)
+		}
+		if file != nil && config.Tenant != nil {
+			if w, err := newTenantWriter(config, file); err != nil {
+				internalLog(zapcore.ErrorLevel, "Failed create tenant log files", Err(err))
+			} else {
+				tenants = w
+				closers = append(closers, w)
+			}
+		}
+	}
+	// the logfile shares the stdout core unless LOG_PRETTY changed the console encoding
+	var fileCore zapcore.Core
+	if file != nil && tenants == nil {
+		if encoding == config.encoding() {
+			writers = append(writers, file)
+		} else {
+			fileCore = newZapCore(config, config.encoding(), file)
+		}
+	}
+
+	core := newZapCore(config, encoding, zapcore.NewMultiWriteSyncer(writers...))
+	if config.Fsync != nil && file != nil {
+		if fileCore != nil {
+			fileCore = newFsyncCore(fileCore, file, *config.Fsync)
+		} else {
+			core = newFsyncCore(core, file, *config.Fsync)
+		}
+		startFsync(file, config.Fsync.Interval)
+	} else {
+		startFsync(nil, 0)
+	}
+	cores := []zapcore.Core{core}
+	if fileCore != nil {
+		cores = append(cores, fileCore)
+	}
+	if tenants != nil {
+		cores = append(cores, newEntryCore(newOutputEncoder(config, config.encoding(), nil), tenants, newSinkLevelEnabler()))
+	}
+	for _, sink := range config.sinks() {
+		core, closer, err := newSinkCore(config, sink)
+		if err != nil {
+			internalLog(zapcore.ErrorLevel, "Failed create sink", String("sink", sink.name()), String("target", sink.target()), Err(err))
+			continue
+		}
+		if closer != nil {
+			closers = append(closers, closer)
+		}
+		cores = append(cores, core)
+	}
+	if config.DualWrite != nil && config.DualWrite.Logrus != nil {
+		cores = append(cores, newDualWriteCore(config))
+	} else {
+		startDualWriteReport(0)
 	}
 
-	DefaultZapLogger = newZapLogger(config.EncodeLogsAsJson, zapcore.NewMultiWriteSyncer(writers...))
-	zap.RedirectStdLog(DefaultZapLogger)
+	core, wrapClosers := wrapCore(zapcore.NewTee(cores...), config)
+	// the async queue drains into the sinks, close it first
+	closers = append(wrapClosers, closers...)
+	logger := zap.New(core, zapOptions(config)...)
+	if config.BuildInfo {
+		logger = logger.With(BuildInfoFields()...)
+	}
+	if config.SchemaVersion > 0 {
+		logger = logger.With(Int(SchemaVersionKey, config.SchemaVersion))
+	}
+	if config.Datadog != nil {
+		logger = logger.With(config.Datadog.withDefaults().fields()...)
+	}
+	zap.RedirectStdLog(logger)
 	//Info("logging configured",
 	//	zap.Bool("fileLogging", config.FileLoggingEnabled),
 	//	zap.Bool("jsonLogOutput", config.EncodeLogsAsJson),
@@ -186,19 +516,23 @@ func Configure(config Config) {
 	//	zap.Int("maxSizeMB", config.MaxSize),
 	//	zap.Int("maxBackups", config.MaxBackups),
 	//	zap.Int("maxAgeInDays", config.MaxAge))
+	DefaultZapLogger = logger
 	DefaultLoggerConfig = config
+	swapState(&loggerState{zap: logger, config: config, closers: closers})
+	startRuntimeStats(config.RuntimeStatsInterval, config.RuntimeStatsLevel)
+	startDiskGuard(config, file)
+	startRetention(config)
+	startManifest(config)
+	startArchive(config)
+	flushInternal()
 }
 
 func Init(file, level string, size, backup int, stackstrace bool) (Log, error) {
 	log := Log{}
 
-	name := filepath.Base(file)
-	if name == "" {
-		return log, errors.New("Bad file")
-	}
-	dir := filepath.Dir(file)
-	if dir == "" {
-		dir = "./"
+	dir, name, err := splitLogFile(file)
+	if err != nil {
+		return log, err
 	}
 	if size < 0 || backup < 0 {
 		return log, errors.New("Bad size or backup")
@@ -226,26 +560,87 @@ func Init(file, level string, size, backup int, stackstrace bool) (Log, error) {
 	return log, nil
 }
 
+// splitLogFile splits the logfile path into directory and filename
+func splitLogFile(file string) (string, string, error) {
+	name := filepath.Base(file)
+	if file == "" || name == "." || name == string(filepath.Separator) {
+		return "", "", errors.New("Bad file")
+	}
+	dir := filepath.Dir(file)
+	if dir == "" {
+		dir = "."
+	}
+	return dir, name, nil
+}
+
 func newRollingFile(config Config) zapcore.WriteSyncer {
-	if err := os.MkdirAll(config.Directory, 0); err != nil {
-		fmt.Printf("Failed create log directory in %s, error: %s\n", config.Directory, err)
+	if config.Directory == "" {
+		config.Directory = "."
+	}
+	if err := createLogDir(config.FileAttrs, config.Directory); err != nil {
+		internalLog(zapcore.ErrorLevel, "Failed create log directory", String("directory", config.Directory), Err(err))
 		return nil
 	}
+	applyFileAttrs(config.FileAttrs, config.Directory)
+
+	path := filepath.Join(config.Directory, config.Filename)
+	if config.Mmap != nil {
+		if w, err := newMmapWriter(path, config.MaxSize, *config.Mmap); err != nil {
+			internalLog(zapcore.ErrorLevel, "Failed create mmap file writer", String("path", path), Err(err))
+		} else {
+			return w
+		}
+	}
+	if config.FileShards > 1 {
+		return newShardedFile(config, path)
+	}
 
-	return zapcore.AddSync(&lumberjack.Logger{
-		Filename:   path.Join(config.Directory, config.Filename),
+	return newRollingFileWriter(newLumberjack(config, path), config.FileAttrs)
+}
+
+func newLumberjack(config Config, path string) *lumberjack.Logger {
+	if config.Retention != nil {
+		config.MaxAge, config.MaxBackups = 0, 0
+	}
+	return &lumberjack.Logger{
+		Filename:   path,
 		MaxSize:    config.MaxSize,    //megabytes
 		MaxAge:     config.MaxAge,     //days
 		MaxBackups: config.MaxBackups, //files
-	})
+	}
 }
 
-func newZapLogger(encodeAsJSON bool, output zapcore.WriteSyncer) *zap.Logger {
+// zapOptions are the options of every zap logger of the package, zap
+// compares levels numerically so it would attach stacks to extra levels
+func zapOptions(config Config) []zap.Option {
+	stacktrace := config.Stacktrace
+	opts := []zap.Option{
+		zap.AddStacktrace(zap.LevelEnablerFunc(func(level zapcore.Level) bool {
+			return stacktrace != nil && levelAtLeast(level, *stacktrace)
+		})),
+	}
+	if needsCaller(config) {
+		// skip the Log method wrapping the zap call
+		opts = append(opts, zap.AddCaller(), zap.AddCallerSkip(1))
+	}
+	return opts
+}
+
+// needsCaller reports whether an output or processor reads the call site
+func needsCaller(config Config) bool {
+	return config.Caller || config.CallerFunc || config.SourceLink != nil || config.ComponentField || filtersNeedCaller(config.Filters)
+}
+
+func newZapCore(config Config, encoding string, output zapcore.WriteSyncer) zapcore.Core {
+	return zapcore.NewCore(newOutputEncoder(config, encoding, output), output, newSinkLevelEnabler())
+}
+
+func newEncoderConfig(config Config) zapcore.EncoderConfig {
 	encCfg := zapcore.EncoderConfig{
 		TimeKey:        "timestamp",
 		LevelKey:       "level",
 		NameKey:        "logger",
-		CallerKey:      "caller",
+		CallerKey:      zapcore.OmitKey,
 		MessageKey:     "msg",
 		StacktraceKey:  "stacktrace",
 		EncodeLevel:    zapcore.LowercaseLevelEncoder,
@@ -253,28 +648,54 @@ func newZapLogger(encodeAsJSON bool, output zapcore.WriteSyncer) *zap.Logger {
 		//EncodeTime:     zapcore.ISO8601TimeEncoder,
 		EncodeTime:     zapcore.EpochMillisTimeEncoder,
 		EncodeDuration: zapcore.NanosDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
-
-	encoder := zapcore.NewConsoleEncoder(encCfg)
-	if encodeAsJSON {
-		encoder = zapcore.NewJSONEncoder(encCfg)
+	if config.Caller {
+		encCfg.CallerKey = "caller"
+	}
+	if config.Datadog != nil {
+		encCfg.LevelKey = "status"
 	}
+	if config.DisableHTMLEscape {
+		encCfg.NewReflectedEncoder = newUnescapedReflectedEncoder
+	}
+
+	return encCfg
+}
 
-	return zap.New(zapcore.NewCore(encoder, output, zap.NewAtomicLevelAt(DefaultLoggerConfig.LogLevel)))
+func newUnescapedReflectedEncoder(w io.Writer) zapcore.ReflectedEncoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc
 }
 
 func SetLogLevel(level string) error {
+	l, err := parseRootLevel(level)
+	if err != nil {
+		return err
+	}
+
+	configureMu.Lock()
+	defer configureMu.Unlock()
+	DefaultLoggerConfig.LogLevel = l
+	rootLevel.Store(int32(l))
+
+	return nil
+}
+
+// parseRootLevel parses the levels SetLogLevel accepts, debug to error and
+// the custom levels
+func parseRootLevel(level string) (zapcore.Level, error) {
 	if level == "debug" {
-		DefaultLoggerConfig.LogLevel = zap.DebugLevel
+		return zap.DebugLevel, nil
 	} else if level == "info" {
-		DefaultLoggerConfig.LogLevel = zap.InfoLevel
+		return zap.InfoLevel, nil
 	} else if level == "warn" {
-		DefaultLoggerConfig.LogLevel = zap.WarnLevel
+		return zap.WarnLevel, nil
 	} else if level == "error" {
-		DefaultLoggerConfig.LogLevel = zap.ErrorLevel
-	} else {
-		return errors.New("Bad log level")
+		return zap.ErrorLevel, nil
+	} else if custom, err := parseLevel(level); err == nil && !standardLevel(custom) {
+		return custom, nil
 	}
-
-	return nil
+	return 0, errors.New("Bad log level")
 }
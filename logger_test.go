@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// discardLogger makes the default logger write json to io.Discard for the
+// duration of the test
+func discardLogger(tb testing.TB) {
+	old := state.Load()
+	core := zapcore.NewCore(newEncoder(Config{}, EncodingJSON), zapcore.AddSync(io.Discard), zapcore.DebugLevel)
+	state.Store(&loggerState{zap: zap.New(core), config: old.config})
+	tb.Cleanup(func() { state.Store(old) })
+}
+
+// logInfo logs one entry with n typed fields, each call site passes its
+// fields as a literal the way callers do
+func logInfo(log *Log, n int) {
+	switch n {
+	case 0:
+		log.Info("Request done")
+	case 1:
+		log.Info("Request done", String("method", "GET"))
+	case 2:
+		log.Info("Request done", String("method", "GET"), Int("status", 200))
+	case 3:
+		log.Info("Request done", String("method", "GET"), Int("status", 200),
+			Bool("cached", true))
+	case 4:
+		log.Info("Request done", String("method", "GET"), Int("status", 200),
+			Bool("cached", true), Duration("took", time.Millisecond))
+	case 5:
+		log.Info("Request done", String("method", "GET"), Int("status", 200),
+			Bool("cached", true), Duration("took", time.Millisecond), String("path", "/"))
+	}
+}
+
+func BenchmarkInfo(b *testing.B) {
+	discardLogger(b)
+	log := &Log{}
+	for n := 0; n <= 5; n++ {
+		b.Run(fmt.Sprintf("%d fields", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				logInfo(log, n)
+			}
+		})
+	}
+}
+
+func TestInfoAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	discardLogger(t)
+	log := &Log{}
+	for n := 0; n <= 5; n++ {
+		if allocs := testing.AllocsPerRun(100, func() { logInfo(log, n) }); allocs != 0 {
+			t.Errorf("Info with %d fields: %v allocs, want 0", n, allocs)
+		}
+	}
+}
+
+func TestConfigureLevelSet(t *testing.T) {
+	old := *currentConfig()
+	t.Cleanup(func() {
+		old.LevelSet = true
+		Configure(old)
+	})
+	if err := SetLogLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+
+	Configure(Config{DisableStdout: true})
+	if got := currentLevel(); got != zapcore.DebugLevel {
+		t.Errorf("unset level: %s, want debug kept", got)
+	}
+	Configure(Config{DisableStdout: true, LevelSet: true})
+	if got := currentLevel(); got != zapcore.InfoLevel {
+		t.Errorf("LevelSet info: %s, want info", got)
+	}
+}
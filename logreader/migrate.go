@@ -0,0 +1,77 @@
+package logreader
+
+import (
+	"sync"
+)
+
+// keySchemaVersion is the field stamped by the logger Config.SchemaVersion
+const keySchemaVersion = "schema_version"
+
+// migrations maps a schema version to the steps upgrading its entries to
+// the next version
+var migrations = struct {
+	sync.RWMutex
+	steps map[int][]func(*Entry)
+	// latest is the version entries are migrated to
+	latest int
+}{steps: make(map[int][]func(*Entry))}
+
+// RegisterMigration adds a step upgrading the entries written with schema
+// version from to version from+1, the steps of a version run in the order
+// they were registered. Entries without a schema_version field are version
+// 0, logged before the logger stamped it.
+func RegisterMigration(from int, step func(e *Entry)) {
+	migrations.Lock()
+	defer migrations.Unlock()
+	migrations.steps[from] = append(migrations.steps[from], step)
+	if from+1 > migrations.latest {
+		migrations.latest = from + 1
+	}
+}
+
+// RenameFields registers a migration from version from renaming the keys
+// of renames to their values, e.g.
+//
+//	logreader.RenameFields(1, map[string]string{"uid": "user_id"})
+func RenameFields(from int, renames map[string]string) {
+	RegisterMigration(from, func(e *Entry) {
+		for old, name := range renames {
+			if v, ok := e.Fields[old]; ok {
+				delete(e.Fields, old)
+				e.Fields[name] = v
+			}
+		}
+	})
+}
+
+// SchemaVersion returns the schema version e was written with
+func SchemaVersion(e Entry) int {
+	switch v := e.Fields[keySchemaVersion].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// Migrate upgrades e to the latest registered schema version, the Reader
+// migrates every entry before filtering it
+func Migrate(e *Entry) {
+	migrations.RLock()
+	defer migrations.RUnlock()
+
+	version := SchemaVersion(*e)
+	if version >= migrations.latest {
+		return
+	}
+	if e.Fields == nil {
+		e.Fields = make(map[string]interface{})
+	}
+	for ; version < migrations.latest; version++ {
+		for _, step := range migrations.steps[version] {
+			step(e)
+		}
+	}
+	e.Fields[keySchemaVersion] = float64(version)
+}
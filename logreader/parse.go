@@ -0,0 +1,200 @@
+package logreader
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Keys written by the logger encoders
+const (
+	keyTime       = "timestamp"
+	keyLevel      = "level"
+	keyLogger     = "logger"
+	keyCaller     = "caller"
+	keyMessage    = "msg"
+	keyStacktrace = "stacktrace"
+)
+
+// Entry is one parsed log entry
+type Entry struct {
+	Time time.Time
+	// Level is the level name as written, e.g. "info" or "notice"
+	Level   string
+	Logger  string
+	Caller  string
+	Message string
+	Stack   string
+	// Fields holds every other key with its JSON decoded value, numbers
+	// are float64
+	Fields map[string]interface{}
+	// Raw is the line the entry was parsed from
+	Raw string
+}
+
+var errNotEntry = errors.New("Not a log entry")
+
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// callerPattern matches the short callers, dir/file.go:42
+var callerPattern = regexp.MustCompile(`^\S+\.go:\d+$`)
+
+// Parse parses one line of the json, console or logfmt output
+func Parse(line string) (Entry, error) {
+	line = strings.TrimRight(line, "\r\n")
+	switch {
+	case strings.HasPrefix(line, "{"):
+		return parseJSON(line)
+	case strings.Contains(line, "\t"):
+		return parseConsole(line)
+	}
+	return parseLogfmt(line)
+}
+
+func parseJSON(line string) (Entry, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &m); err != nil {
+		return Entry{}, err
+	}
+	e := Entry{Raw: line, Fields: m}
+	e.Time, _ = parseTime(m[keyTime])
+	e.Level = takeString(m, keyLevel)
+	e.Logger = takeString(m, keyLogger)
+	e.Caller = takeString(m, keyCaller)
+	e.Message = takeString(m, keyMessage)
+	e.Stack = takeString(m, keyStacktrace)
+	delete(m, keyTime)
+	return e, nil
+}
+
+// parseConsole parses time, level, logger, caller, message and the JSON
+// fields separated by tabs, logger and caller being optional
+func parseConsole(line string) (Entry, error) {
+	parts := strings.Split(ansiEscape.ReplaceAllString(line, ""), "\t")
+	if len(parts) < 3 {
+		return Entry{}, errNotEntry
+	}
+	t, ok := parseTime(parts[0])
+	if !ok {
+		return Entry{}, errNotEntry
+	}
+	e := Entry{Raw: line, Time: t, Level: strings.ToLower(parts[1]), Fields: map[string]interface{}{}}
+
+	rest := parts[2:]
+	if last := rest[len(rest)-1]; len(rest) > 1 && strings.HasPrefix(last, "{") {
+		if err := json.Unmarshal([]byte(last), &e.Fields); err == nil {
+			rest = rest[:len(rest)-1]
+		}
+	}
+	e.Message = strings.Join(rest[len(rest)-1:], "")
+	for _, p := range rest[:len(rest)-1] {
+		if callerPattern.MatchString(p) {
+			e.Caller = p
+		} else {
+			e.Logger = p
+		}
+	}
+	e.Stack = takeString(e.Fields, keyStacktrace)
+	return e, nil
+}
+
+// parseLogfmt parses key=value pairs, values may be double quoted
+func parseLogfmt(line string) (Entry, error) {
+	m := make(map[string]interface{})
+	s := strings.TrimSpace(line)
+	for s != "" {
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || strings.IndexFunc(s[:eq], unicode.IsSpace) >= 0 {
+			return Entry{}, errNotEntry
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := 1
+			for end < len(s) && (s[end] != '"' || s[end-1] == '\\') {
+				end++
+			}
+			if end == len(s) {
+				return Entry{}, errNotEntry
+			}
+			unquoted, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return Entry{}, err
+			}
+			value, s = unquoted, s[end+1:]
+		} else if sp := strings.IndexByte(s, ' '); sp >= 0 {
+			value, s = s[:sp], s[sp:]
+		} else {
+			value, s = s, ""
+		}
+		m[key] = logfmtValue(value)
+		s = strings.TrimLeft(s, " ")
+	}
+	if len(m) == 0 {
+		return Entry{}, errNotEntry
+	}
+
+	e := Entry{Raw: line, Fields: m}
+	for _, k := range []string{keyTime, "ts", "time"} {
+		if t, ok := parseTime(m[k]); ok {
+			e.Time = t
+			delete(m, k)
+			break
+		}
+	}
+	e.Level = takeString(m, keyLevel)
+	e.Logger = takeString(m, keyLogger)
+	e.Caller = takeString(m, keyCaller)
+	e.Message = takeString(m, keyMessage)
+	e.Stack = takeString(m, keyStacktrace)
+	return e, nil
+}
+
+func logfmtValue(s string) interface{} {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	if b, err := strconv.ParseBool(s); err == nil {
+		return b
+	}
+	return s
+}
+
+// parseTime reads epoch milliseconds, the default of the logger, and
+// RFC 3339 or ISO 8601 times
+func parseTime(v interface{}) (time.Time, bool) {
+	switch v := v.(type) {
+	case float64:
+		return time.UnixMicro(int64(v * 1000)), true
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return time.UnixMicro(int64(f * 1000)), true
+		}
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000Z0700"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// takeString removes key from m and returns its string value
+func takeString(m map[string]interface{}, key string) string {
+	v, ok := m[key]
+	if !ok {
+		return ""
+	}
+	delete(m, key)
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
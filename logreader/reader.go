@@ -0,0 +1,186 @@
+// Package logreader parses the output of the json and console encodings
+// of github.com/gwtony/logger, and logfmt lines, back into entries:
+//
+//	r := logreader.NewReader(f).Filter(logreader.Filter{MinLevel: "warn"})
+//	for r.Next() {
+//		e := r.Entry()
+//		fmt.Println(e.Time, e.Level, e.Message, e.Fields["user_id"])
+//	}
+//	if err := r.Err(); err != nil {
+//		...
+//	}
+package logreader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Filter selects entries, the zero value selects every entry
+type Filter struct {
+	// Since and Until bound the entry time, unbounded when zero
+	Since time.Time
+	Until time.Time
+	// MinLevel drops the entries below this level, the levels unknown to
+	// the reader are kept
+	MinLevel string
+	// Levels keeps only the entries at one of these levels
+	Levels []string
+	// Logger keeps the entries of this logger and its descendants
+	Logger string
+	// Fields keeps the entries whose fields, rendered like fmt %v, have
+	// these values
+	Fields map[string]string
+}
+
+// levelRanks orders the levels of the logger, extra levels included
+var levelRanks = map[string]int{
+	"debug":    -10,
+	"info":     0,
+	"notice":   5,
+	"warn":     10,
+	"warning":  10,
+	"error":    20,
+	"critical": 25,
+	"dpanic":   30,
+	"panic":    40,
+	"fatal":    50,
+}
+
+// Match reports whether f selects e
+func (f Filter) Match(e Entry) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	level := strings.ToLower(e.Level)
+	if f.MinLevel != "" {
+		min, ok := levelRanks[strings.ToLower(f.MinLevel)]
+		if rank, known := levelRanks[level]; ok && known && rank < min {
+			return false
+		}
+	}
+	if len(f.Levels) > 0 {
+		found := false
+		for _, l := range f.Levels {
+			found = found || strings.EqualFold(l, level)
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Logger != "" && e.Logger != f.Logger && !strings.HasPrefix(e.Logger, f.Logger+".") {
+		return false
+	}
+	for k, want := range f.Fields {
+		v, ok := e.Fields[k]
+		if !ok || fmt.Sprint(v) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// Reader iterates over the entries of a log stream, the lines of a
+// console stack trace are joined to the entry they follow and lines that
+// do not parse are skipped. Entries are migrated to the latest schema
+// version, see RegisterMigration.
+type Reader struct {
+	scanner *bufio.Scanner
+	filter  Filter
+	// pending is the entry read ahead while looking for its stack lines
+	pending *Entry
+	entry   Entry
+	err     error
+}
+
+// NewReader reads entries from r
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+	return &Reader{scanner: scanner}
+}
+
+// Filter restricts the entries returned by Next
+func (r *Reader) Filter(f Filter) *Reader {
+	r.filter = f
+	return r
+}
+
+// Next advances to the next selected entry, false at the end of the
+// stream or on error
+func (r *Reader) Next() bool {
+	for {
+		e, ok := r.read()
+		if !ok {
+			return false
+		}
+		Migrate(&e)
+		if r.filter.Match(e) {
+			r.entry = e
+			return true
+		}
+	}
+}
+
+// read returns the next entry with its stack lines
+func (r *Reader) read() (Entry, bool) {
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		e, err := Parse(line)
+		if err != nil {
+			if r.pending != nil && strings.TrimSpace(line) != "" {
+				if r.pending.Stack != "" {
+					r.pending.Stack += "\n"
+				}
+				r.pending.Stack += line
+				r.pending.Raw += "\n" + line
+			}
+			continue
+		}
+		prev := r.pending
+		r.pending = &e
+		if prev != nil {
+			return *prev, true
+		}
+	}
+	r.err = r.scanner.Err()
+	if r.pending != nil {
+		e := *r.pending
+		r.pending = nil
+		return e, true
+	}
+	return Entry{}, false
+}
+
+// Entry returns the entry Next advanced to
+func (r *Reader) Entry() Entry {
+	return r.entry
+}
+
+// Err returns the read error that stopped Next, nil at the end of the stream
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// ReadFile returns the entries of the file at path selected by f
+func ReadFile(path string, f Filter) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	r := NewReader(file).Filter(f)
+	for r.Next() {
+		entries = append(entries, r.Entry())
+	}
+	return entries, r.Err()
+}
@@ -0,0 +1,219 @@
+package logreader
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tailInterval is the poll interval of Tail
+var tailInterval = 250 * time.Millisecond
+
+// Tail follows the newest file matching the glob pattern, e.g.
+// "/var/log/app/app.log", from its current end and sends its entries on
+// the returned channel until ctx is done. A rotation, the file renamed and
+// a new one created in its place, is followed once the old file is read
+// to its end, so is a newer file matching pattern. A file truncated below
+// the read offset is read again from the start.
+func Tail(ctx context.Context, pattern string) (<-chan Entry, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	t := &tailer{pattern: pattern, entries: make(chan Entry, 64)}
+	go t.run(ctx)
+	return t.entries, nil
+}
+
+type tailer struct {
+	pattern string
+	entries chan Entry
+
+	path    string
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	partial []byte
+	pending *Entry
+}
+
+func (t *tailer) run(ctx context.Context) {
+	defer close(t.entries)
+	defer t.close()
+
+	t.open(true)
+	ticker := time.NewTicker(tailInterval)
+	defer ticker.Stop()
+	for {
+		if !t.poll(ctx) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newest returns the most recently modified file matching the pattern
+func (t *tailer) newest() string {
+	matches, _ := filepath.Glob(t.pattern)
+	newest := ""
+	var newestTime time.Time
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if newest == "" || info.ModTime().After(newestTime) {
+			newest, newestTime = m, info.ModTime()
+		}
+	}
+	return newest
+}
+
+// open follows the newest file, from its end when atEnd
+func (t *tailer) open(atEnd bool) {
+	path := t.newest()
+	if path == "" {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return
+	}
+	t.close()
+	t.path, t.file, t.info, t.offset = path, f, info, 0
+	if atEnd {
+		t.offset = info.Size()
+	}
+}
+
+func (t *tailer) close() {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
+
+// poll reads what was appended then checks for a rotation, it returns
+// false once ctx is done
+func (t *tailer) poll(ctx context.Context) bool {
+	if t.file == nil {
+		t.open(false)
+		if t.file == nil {
+			return true
+		}
+	}
+
+	if info, err := t.file.Stat(); err == nil && info.Size() < t.offset {
+		// truncated in place
+		t.offset = 0
+		t.partial = t.partial[:0]
+	}
+	read, ok := t.read(ctx)
+	if !ok {
+		return false
+	}
+
+	// the old file is drained, switch when the path now holds another
+	// file or a newer file matches
+	rotated := false
+	if info, err := os.Stat(t.path); err != nil || !os.SameFile(info, t.info) {
+		rotated = true
+	} else if newest := t.newest(); newest != "" && newest != t.path {
+		rotated = true
+	}
+	if rotated {
+		if !t.flushPartial(ctx) {
+			return false
+		}
+		t.open(false)
+		return t.flush(ctx)
+	}
+	if !read {
+		// nothing new, the pending entry has no more stack lines to wait for
+		if len(t.partial) == 0 {
+			return t.flush(ctx)
+		}
+	}
+	return true
+}
+
+// read sends the complete lines appended since the last read, read is
+// true when data was appended
+func (t *tailer) read(ctx context.Context) (read bool, ok bool) {
+	buf := make([]byte, 64<<10)
+	for {
+		n, err := t.file.ReadAt(buf, t.offset)
+		if n > 0 {
+			read = true
+			t.offset += int64(n)
+			t.partial = append(t.partial, buf[:n]...)
+			for {
+				nl := bytes.IndexByte(t.partial, '\n')
+				if nl < 0 {
+					break
+				}
+				line := string(t.partial[:nl])
+				t.partial = t.partial[nl+1:]
+				if !t.line(ctx, line) {
+					return read, false
+				}
+			}
+		}
+		if err != nil || n == 0 {
+			// io.EOF or a read error, retried on the next poll
+			return read, true
+		}
+	}
+}
+
+// flushPartial treats a last line without newline as complete
+func (t *tailer) flushPartial(ctx context.Context) bool {
+	line := string(t.partial)
+	t.partial = t.partial[:0]
+	return line == "" || t.line(ctx, line)
+}
+
+// line parses line, stack lines are joined to the pending entry
+func (t *tailer) line(ctx context.Context, line string) bool {
+	e, err := Parse(line)
+	if err != nil {
+		if t.pending != nil && strings.TrimSpace(line) != "" {
+			if t.pending.Stack != "" {
+				t.pending.Stack += "\n"
+			}
+			t.pending.Stack += line
+			t.pending.Raw += "\n" + line
+		}
+		return true
+	}
+	if !t.flush(ctx) {
+		return false
+	}
+	t.pending = &e
+	return true
+}
+
+// flush sends the pending entry
+func (t *tailer) flush(ctx context.Context) bool {
+	if t.pending == nil {
+		return true
+	}
+	select {
+	case t.entries <- *t.pending:
+		t.pending = nil
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,209 @@
+package logger
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gwtony/logger/logreader"
+	"go.uber.org/zap/zapcore"
+)
+
+// ManifestEntry is one line of the manifest of the rotated logfiles, see
+// Config.Manifest
+type ManifestEntry struct {
+	// File is the name of the rotated file in the log directory
+	File   string `json:"file"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// First and Last are the times of the first and last entries, nil
+	// when the encoding cannot be read back
+	First *time.Time `json:"first,omitempty"`
+	Last  *time.Time `json:"last,omitempty"`
+	// Written is when the line was added
+	Written time.Time `json:"written"`
+}
+
+// manifestPath is the manifest of the logfile name in dir
+func manifestPath(dir, name string) string {
+	return filepath.Join(dir, name+".manifest.jsonl")
+}
+
+// ReadManifest reads the manifest at path, e.g. /var/log/app/app.log.manifest.jsonl
+func ReadManifest(path string) ([]ManifestEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []ManifestEntry
+	dec := json.NewDecoder(f)
+	for {
+		var e ManifestEntry
+		if err := dec.Decode(&e); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return entries, err
+		}
+		entries = append(entries, e)
+	}
+}
+
+var (
+	manifestMu   sync.Mutex
+	manifestStop chan struct{}
+	// manifestWake asks for the manifest to be updated after a rotation
+	manifestWake = make(chan struct{}, 1)
+)
+
+// notifyRotation wakes the manifest writer and the archiver, if any
+func notifyRotation() {
+	for _, wake := range []chan struct{}{manifestWake, archiveWake} {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// startManifest replaces the running manifest writer, it adds the rotated
+// files missing from the manifest right away, after every rotation and
+// every minute
+func startManifest(config Config) {
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+
+	if manifestStop != nil {
+		close(manifestStop)
+		manifestStop = nil
+	}
+	if !config.Manifest || !config.FileLoggingEnabled || config.Filename == "" {
+		return
+	}
+	dir := config.Directory
+	if dir == "" {
+		dir = "."
+	}
+
+	stop := make(chan struct{})
+	manifestStop = stop
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			for _, name := range retainedLogfiles(config) {
+				if err := updateManifest(dir, name); err != nil {
+					internalLog(zapcore.WarnLevel, "Failed update log file manifest", String("file", name), Err(err))
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-manifestWake:
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// updateManifest appends the rotated files of name missing from its manifest
+func updateManifest(dir, name string) error {
+	path := manifestPath(dir, name)
+	entries, err := ReadManifest(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	listed := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		listed[e.File] = true
+	}
+
+	files, err := rotatedFiles(dir, name)
+	if err != nil {
+		return err
+	}
+	var out *os.File
+	for _, f := range files {
+		base := filepath.Base(f.Path)
+		if listed[base] {
+			continue
+		}
+		e, err := manifestEntry(f.Path)
+		if err != nil {
+			internalLog(zapcore.WarnLevel, "Failed checksum rotated log file", String("file", f.Path), Err(err))
+			continue
+		}
+		if out == nil {
+			if out, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644); err != nil {
+				return err
+			}
+			defer out.Close()
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err := out.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	if out != nil {
+		return out.Sync()
+	}
+	return nil
+}
+
+// manifestEntry hashes the file at path and reads the time range of its
+// entries on the way
+func manifestEntry(path string) (ManifestEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	var size int64
+	var first, last time.Time
+	r := bufio.NewReader(io.TeeReader(f, h))
+	for {
+		line, err := r.ReadString('\n')
+		size += int64(len(line))
+		if line != "" && !isGzipPath(path) {
+			if e, perr := logreader.Parse(line); perr == nil && !e.Time.IsZero() {
+				if first.IsZero() {
+					first = e.Time
+				}
+				last = e.Time
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return ManifestEntry{}, err
+		}
+	}
+
+	e := ManifestEntry{
+		File:    filepath.Base(path),
+		Size:    size,
+		SHA256:  hex.EncodeToString(h.Sum(nil)),
+		Written: time.Now().UTC(),
+	}
+	if !first.IsZero() {
+		e.First, e.Last = &first, &last
+	}
+	return e, nil
+}
+
+func isGzipPath(path string) bool {
+	return filepath.Ext(path) == ".gz"
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ObjectEncoder and ArrayEncoder are written to by marshalers, they are the
+// zapcore types so marshalers written for zap work unchanged
+type ObjectEncoder = zapcore.ObjectEncoder
+type ArrayEncoder = zapcore.ArrayEncoder
+
+// ObjectMarshaler is implemented by types logging themselves as an object
+type ObjectMarshaler = zapcore.ObjectMarshaler
+
+// ArrayMarshaler is implemented by types logging themselves as an array
+type ArrayMarshaler = zapcore.ArrayMarshaler
+
+// Object logs a sub-object built by fn, e.g.
+//
+//	logger.Object("user", func(enc logger.ObjectEncoder) error {
+//		enc.AddString("name", u.Name)
+//		enc.AddInt("age", u.Age)
+//		return nil
+//	})
+func Object(name string, fn func(ObjectEncoder) error) zapcore.Field {
+	return zap.Object(name, zapcore.ObjectMarshalerFunc(fn))
+}
+
+// Array logs an array built by fn
+func Array(name string, fn func(ArrayEncoder) error) zapcore.Field {
+	return zap.Array(name, zapcore.ArrayMarshalerFunc(fn))
+}
+
+// Marshal logs a value implementing ObjectMarshaler
+func Marshal(name string, value ObjectMarshaler) zapcore.Field {
+	return zap.Object(name, value)
+}
+
+// Objects logs a slice of ObjectMarshaler values as an array
+func Objects[T ObjectMarshaler](name string, values []T) zapcore.Field {
+	return zap.Array(name, zapcore.ArrayMarshalerFunc(func(enc ArrayEncoder) error {
+		for _, v := range values {
+			if err := enc.AppendObject(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
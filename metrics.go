@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap/zapcore"
+)
+
+type metricKind int
+
+const (
+	metricCounter metricKind = iota + 1
+	metricGauge
+)
+
+// metricField builds a float field carrying its metric kind, encoders only
+// read Integer for floats so the marker is invisible in the output
+func metricField(name string, value float64, kind metricKind) zapcore.Field {
+	return zapcore.Field{Key: name, Type: zapcore.Float64Type, Integer: int64(math.Float64bits(value)), Interface: kind}
+}
+
+// Counter logs value and, when Config.MetricsRegisterer is set, adds it to
+// the prometheus counter name:
+//
+//	log.Info("Cache miss", logger.Counter("cache_miss", 1))
+//
+// Only entries passing the level are counted.
+func Counter(name string, value float64) zapcore.Field {
+	return metricField(name, value, metricCounter)
+}
+
+// Gauge logs value and, when Config.MetricsRegisterer is set, sets the
+// prometheus gauge name to it
+func Gauge(name string, value float64) zapcore.Field {
+	return metricField(name, value, metricGauge)
+}
+
+type metricsCollector struct {
+	reg       prometheus.Registerer
+	namespace string
+	mu        sync.Mutex
+	counters  map[string]prometheus.Counter
+	gauges    map[string]prometheus.Gauge
+}
+
+func newMetricsCollector(reg prometheus.Registerer, namespace string) *metricsCollector {
+	return &metricsCollector{
+		reg:       reg,
+		namespace: namespace,
+		counters:  make(map[string]prometheus.Counter),
+		gauges:    make(map[string]prometheus.Gauge),
+	}
+}
+
+func metricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == ':' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// register returns the already registered collector on conflicts
+func (m *metricsCollector) register(c prometheus.Collector) prometheus.Collector {
+	if err := m.reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return are.ExistingCollector
+		}
+		internalLog(zapcore.ErrorLevel, "Failed register log metric", Err(err))
+	}
+	return c
+}
+
+func (m *metricsCollector) counter(name string) prometheus.Counter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.counters[name]
+	if !ok {
+		c, ok = m.register(prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: m.namespace,
+			Name:      metricName(name),
+			Help:      "Counter derived from log field " + name,
+		})).(prometheus.Counter)
+		if !ok {
+			return nil
+		}
+		m.counters[name] = c
+	}
+	return c
+}
+
+func (m *metricsCollector) gauge(name string) prometheus.Gauge {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	g, ok := m.gauges[name]
+	if !ok {
+		g, ok = m.register(prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: m.namespace,
+			Name:      metricName(name),
+			Help:      "Gauge derived from log field " + name,
+		})).(prometheus.Gauge)
+		if !ok {
+			return nil
+		}
+		m.gauges[name] = g
+	}
+	return g
+}
+
+func (m *metricsCollector) process(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+	for _, f := range fields {
+		kind, ok := f.Interface.(metricKind)
+		if !ok || f.Type != zapcore.Float64Type {
+			continue
+		}
+		value := math.Float64frombits(uint64(f.Integer))
+		switch kind {
+		case metricCounter:
+			if c := m.counter(f.Key); c != nil && value >= 0 {
+				c.Add(value)
+			}
+		case metricGauge:
+			if g := m.gauge(f.Key); g != nil {
+				g.Set(value)
+			}
+		}
+	}
+	return ent, fields, true
+}
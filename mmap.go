@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"time"
+)
+
+// MmapConfig configures the experimental memory-mapped file writer, only
+// supported on linux, other systems fall back to the rolling file
+//
+// The file grows by ChunkSize and is truncated to its content on close and
+// rotation. Rotation follows MaxSize, rolled files are named like lumberjack
+// backups but MaxBackups and MaxAge are not applied to them.
+type MmapConfig struct {
+	// ChunkSize is the size of the mapped window, rounded to pages, default 64MB
+	ChunkSize int
+	// SyncInterval is how often dirty pages are written back with msync,
+	// default 1s, negative leaves it to the kernel
+	SyncInterval time.Duration
+}
+
+func (c MmapConfig) withDefaults() MmapConfig {
+	if c.ChunkSize <= 0 {
+		c.ChunkSize = 64 << 20
+	}
+	if c.SyncInterval == 0 {
+		c.SyncInterval = time.Second
+	}
+	return c
+}
@@ -0,0 +1,236 @@
+//go:build linux
+
+package logger
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// mmapWriter appends into a mapped window of the file, the window starts
+// on a page boundary and moves forward when full
+type mmapWriter struct {
+	config  MmapConfig
+	path    string
+	maxSize int64
+	mu      sync.Mutex
+	file    *os.File
+	// data maps the file from offset, size is the length of the content
+	data   []byte
+	offset int64
+	size   int64
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func newMmapWriter(path string, maxSize int, config MmapConfig) (zapcore.WriteSyncer, error) {
+	config = config.withDefaults()
+	page := os.Getpagesize()
+	config.ChunkSize = (config.ChunkSize + page - 1) / page * page
+
+	w := &mmapWriter{config: config, path: path, maxSize: int64(maxSize) << 20}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	if config.SyncInterval > 0 {
+		w.stop = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.syncLoop()
+	}
+	return w, nil
+}
+
+// open maps the end of the file, dropping the zero tail left when the
+// process died before truncating it
+func (w *mmapWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	size, err := contentSize(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file = f
+	w.size = size
+	if err := w.remap(); err != nil {
+		f.Close()
+		return err
+	}
+	return nil
+}
+
+// contentSize is the file size without trailing zero bytes
+func contentSize(f *os.File) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	buf := make([]byte, 64<<10)
+	for size > 0 {
+		n := int64(len(buf))
+		if n > size {
+			n = size
+		}
+		if _, err := f.ReadAt(buf[:n], size-n); err != nil {
+			return 0, err
+		}
+		for i := n - 1; i >= 0; i-- {
+			if buf[i] != 0 {
+				return size - n + i + 1, nil
+			}
+		}
+		size -= n
+	}
+	return 0, nil
+}
+
+// remap maps a window starting at the page holding size
+func (w *mmapWriter) remap() error {
+	if w.data != nil {
+		if err := syscall.Munmap(w.data); err != nil {
+			return err
+		}
+		w.data = nil
+	}
+
+	page := int64(os.Getpagesize())
+	w.offset = w.size / page * page
+	if err := w.file.Truncate(w.offset + int64(w.config.ChunkSize)); err != nil {
+		return err
+	}
+	data, err := syscall.Mmap(int(w.file.Fd()), w.offset, w.config.ChunkSize, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return err
+	}
+	w.data = data
+	return nil
+}
+
+func (w *mmapWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.data == nil {
+		return 0, errors.New("Mmap file writer is closed")
+	}
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	written := 0
+	for written < len(p) {
+		pos := int(w.size - w.offset)
+		if pos == len(w.data) {
+			if err := w.remap(); err != nil {
+				return written, err
+			}
+			pos = int(w.size - w.offset)
+		}
+		n := copy(w.data[pos:], p[written:])
+		written += n
+		w.size += int64(n)
+	}
+	return written, nil
+}
+
+// unmap writes the window back and truncates the file to its content
+func (w *mmapWriter) unmap() error {
+	if w.data == nil {
+		return nil
+	}
+	err := msync(w.data)
+	if uerr := syscall.Munmap(w.data); err == nil {
+		err = uerr
+	}
+	w.data = nil
+	if terr := w.file.Truncate(w.size); err == nil {
+		err = terr
+	}
+	return err
+}
+
+// rotate renames the file like a lumberjack backup and starts a new one
+func (w *mmapWriter) rotate() error {
+	if err := w.unmap(); err != nil {
+		return err
+	}
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	ext := ""
+	if i := strings.LastIndexByte(w.path, '.'); i > strings.LastIndexByte(w.path, os.PathSeparator) {
+		ext = w.path[i:]
+	}
+	backup := strings.TrimSuffix(w.path, ext) + "-" + time.Now().Format("2006-01-02T15-04-05.000") + ext
+	if err := os.Rename(w.path, backup); err != nil {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	internalLog(zapcore.InfoLevel, "Log file rotated", String("file", w.path), String("backup", backup))
+	return nil
+}
+
+func msync(data []byte) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_MSYNC, uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)), syscall.MS_SYNC)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (w *mmapWriter) syncLoop() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.config.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.Sync()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// Sync writes the dirty pages of the window back to the file
+func (w *mmapWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.data == nil {
+		return nil
+	}
+	return msync(w.data)
+}
+
+func (w *mmapWriter) Close() error {
+	if w.stop != nil {
+		close(w.stop)
+		<-w.done
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.unmap()
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package logger
+
+import (
+	"errors"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func newMmapWriter(path string, maxSize int, config MmapConfig) (zapcore.WriteSyncer, error) {
+	return nil, errors.New("Mmap file writer is only supported on linux")
+}
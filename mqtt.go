@@ -0,0 +1,176 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"go.uber.org/zap/zapcore"
+)
+
+// MQTTConfig configures the MQTT sink, entries are published as JSON
+type MQTTConfig struct {
+	// Broker URL, e.g. tcp://broker:1883 or ssl://broker:8883
+	Broker string
+	// ClientID defaults to the device id
+	ClientID string
+	Username string
+	Password string
+	// DeviceID fills {device} in Topic, default is the hostname
+	DeviceID string
+	// Topic template, {device}, {level}, {logger} and {<field>} are replaced,
+	// e.g. "devices/{device}/logs/{level}"
+	Topic string
+	// QoS is 0, 1 or 2
+	QoS byte
+	// Retained sets the retained flag on every message
+	Retained bool
+	// OfflineBuffer is the number of entries kept while disconnected, oldest
+	// are dropped first, default 1000
+	OfflineBuffer int
+	// PublishTimeout bounds waiting for a publish token on Sync, default 5s
+	PublishTimeout time.Duration
+	// TLS enables TLS when not nil
+	TLS *TLSConfig
+}
+
+type mqttMessage struct {
+	topic   string
+	payload []byte
+}
+
+type mqttWriter struct {
+	config  MQTTConfig
+	client  mqtt.Client
+	mu      sync.Mutex
+	offline []mqttMessage
+	pending []mqtt.Token
+}
+
+func newMQTTWriter(config MQTTConfig) (*mqttWriter, error) {
+	if config.Broker == "" || config.Topic == "" {
+		return nil, errors.New("Bad mqtt broker or topic")
+	}
+	if config.QoS > 2 {
+		return nil, errors.New("Bad mqtt qos")
+	}
+	if config.DeviceID == "" {
+		config.DeviceID, _ = os.Hostname()
+	}
+	if config.ClientID == "" {
+		config.ClientID = config.DeviceID
+	}
+	if config.OfflineBuffer <= 0 {
+		config.OfflineBuffer = 1000
+	}
+	if config.PublishTimeout <= 0 {
+		config.PublishTimeout = 5 * time.Second
+	}
+	config.Topic = strings.ReplaceAll(config.Topic, "{device}", config.DeviceID)
+
+	w := &mqttWriter{config: config}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(config.Broker).
+		SetClientID(config.ClientID).
+		SetUsername(config.Username).
+		SetPassword(config.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOnConnectHandler(func(mqtt.Client) {
+			internalLog(zapcore.InfoLevel, "Sink connected", String("sink", "mqtt"), String("address", config.Broker))
+			w.drain()
+		}).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			internalLog(zapcore.WarnLevel, "Sink connection lost", String("sink", "mqtt"), String("address", config.Broker), Err(err))
+		})
+	if config.TLS != nil {
+		tlsConfig, err := config.TLS.Build()
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	// with connect retry the token only completes once connected, do not wait for it
+	w.client = mqtt.NewClient(opts)
+	w.client.Connect()
+
+	return w, nil
+}
+
+func (w *mqttWriter) WriteEntry(ent zapcore.Entry, fields []zapcore.Field, encoded []byte) error {
+	msg := mqttMessage{
+		topic:   expandTemplate(w.config.Topic, ent, fieldMap(fields), "none"),
+		payload: append([]byte(nil), bytes.TrimRight(encoded, "\n")...),
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.client.IsConnectionOpen() {
+		if len(w.offline) >= w.config.OfflineBuffer {
+			w.offline = w.offline[1:]
+		}
+		w.offline = append(w.offline, msg)
+		return nil
+	}
+	w.publish(msg)
+	return nil
+}
+
+// publish must be called with mu held
+func (w *mqttWriter) publish(msg mqttMessage) {
+	token := w.client.Publish(msg.topic, w.config.QoS, w.config.Retained, msg.payload)
+	w.pending = append(w.pending, token)
+	// keep the pending list bounded, completed tokens are discarded
+	if len(w.pending) > w.config.OfflineBuffer {
+		live := w.pending[:0]
+		for _, t := range w.pending {
+			select {
+			case <-t.Done():
+			default:
+				live = append(live, t)
+			}
+		}
+		w.pending = live
+	}
+}
+
+func (w *mqttWriter) drain() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, msg := range w.offline {
+		w.publish(msg)
+	}
+	w.offline = nil
+}
+
+func (w *mqttWriter) Sync() error {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+
+	deadline := time.Now().Add(w.config.PublishTimeout)
+	for _, token := range pending {
+		if !token.WaitTimeout(time.Until(deadline)) {
+			return errors.New("Timeout waiting for mqtt publish")
+		}
+		if err := token.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *mqttWriter) Close() error {
+	err := w.Sync()
+	w.client.Disconnect(uint(w.config.PublishTimeout / time.Millisecond))
+	return err
+}
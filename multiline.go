@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Multiline policies, see Config.Multiline
+const (
+	// MultilineEscape writes the newlines of the message, the stack trace
+	// and the string fields as \n so every entry stays on one line
+	MultilineEscape = "escape"
+	// MultilineLines cuts a multiline message to its first line and adds
+	// all of its lines as "lines", the stack trace becomes an array of lines
+	MultilineLines = "lines"
+)
+
+var newlineEscaper = strings.NewReplacer("\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func splitLines(s string) []string {
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
+
+func newMultilineProcess(policy string) processFunc {
+	return func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+		if strings.ContainsAny(ent.Message, "\r\n") {
+			if policy == MultilineLines {
+				lines := splitLines(ent.Message)
+				ent.Message = lines[0]
+				fields = append(fields, Strings("lines", lines))
+			} else {
+				ent.Message = newlineEscaper.Replace(ent.Message)
+			}
+		}
+		// the stack moves into a field, encoders write ent.Stack on lines of its own
+		if ent.Stack != "" {
+			if policy == MultilineLines {
+				fields = append(fields, Strings("stacktrace", splitLines(ent.Stack)))
+			} else {
+				fields = append(fields, String("stacktrace", newlineEscaper.Replace(ent.Stack)))
+			}
+			ent.Stack = ""
+		}
+		for i := range fields {
+			if fields[i].Type == zapcore.StringType && strings.ContainsAny(fields[i].String, "\r\n") {
+				fields[i].String = newlineEscaper.Replace(fields[i].String)
+			}
+		}
+		return ent, fields, true
+	}
+}
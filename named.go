@@ -0,0 +1,209 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Named loggers form a hierarchy by their dotted names, a logger without a
+// level of its own uses the level of its closest ancestor, the root being
+// Config.LogLevel:
+//
+//	logger.SetNamedLevel("svc.db", "debug")
+//	log := logger.Named("svc.db.pool") // logs at debug
+var namedLevels = struct {
+	mu     sync.RWMutex
+	levels map[string]zapcore.Level
+	// count of levels set, checks skip the hierarchy while it is 0
+	count atomic.Int32
+	// resolved caches the namedOverride per logger name
+	resolved sync.Map
+}{levels: make(map[string]zapcore.Level)}
+
+// namedZap caches the zap logger of every name, rebuilt when
+// Configure replaces the logger
+var namedZap sync.Map // string -> namedZapLogger
+
+type namedZapLogger struct {
+	base   *zap.Logger
+	logger *zap.Logger
+}
+
+// Named returns the logger called name, use dots to build a hierarchy
+func Named(name string) *Log {
+	return &Log{name: name}
+}
+
+// Named returns the child logger called name under l
+func (l *Log) Named(name string) *Log {
+	child := *l
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	child.name = name
+	return &child
+}
+
+func namedZapFor(name string) *zap.Logger {
+	base := currentZap()
+	if v, ok := namedZap.Load(name); ok && v.(namedZapLogger).base == base {
+		return v.(namedZapLogger).logger
+	}
+	logger := base.Named(name)
+	namedZap.Store(name, namedZapLogger{base: base, logger: logger})
+	return logger
+}
+
+// SetNamedLevel sets the level of the named logger and its descendants
+// without a level of their own, it takes effect immediately
+func SetNamedLevel(name, level string) error {
+	l, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	namedLevels.mu.Lock()
+	defer namedLevels.mu.Unlock()
+	namedLevels.levels[name] = l
+	namedLevels.count.Store(int32(len(namedLevels.levels)))
+	resetNamedLevels()
+	return nil
+}
+
+// ClearNamedLevel removes the level of the named logger, it inherits again
+func ClearNamedLevel(name string) {
+	namedLevels.mu.Lock()
+	defer namedLevels.mu.Unlock()
+	delete(namedLevels.levels, name)
+	namedLevels.count.Store(int32(len(namedLevels.levels)))
+	resetNamedLevels()
+}
+
+// NamedLevels returns the levels set by SetNamedLevel
+func NamedLevels() map[string]zapcore.Level {
+	namedLevels.mu.RLock()
+	defer namedLevels.mu.RUnlock()
+
+	levels := make(map[string]zapcore.Level, len(namedLevels.levels))
+	for name, level := range namedLevels.levels {
+		levels[name] = level
+	}
+	return levels
+}
+
+// resetNamedLevels drops the resolved levels, namedLevels.mu must be held
+func resetNamedLevels() {
+	namedLevels.resolved.Range(func(k, _ interface{}) bool {
+		namedLevels.resolved.Delete(k)
+		return true
+	})
+}
+
+// namedOverride is the resolved level of a name, set when the name or an
+// ancestor has a level of its own
+type namedOverride struct {
+	level zapcore.Level
+	set   bool
+}
+
+// effectiveLevel walks name up to the root to find its level, only the
+// overrides are cached so root level changes apply at once
+func effectiveLevel(name string) zapcore.Level {
+	if namedLevels.count.Load() == 0 {
+		return currentLevel()
+	}
+	if v, ok := namedLevels.resolved.Load(name); ok {
+		if o := v.(namedOverride); o.set {
+			return o.level
+		}
+		return currentLevel()
+	}
+
+	namedLevels.mu.RLock()
+	defer namedLevels.mu.RUnlock()
+	var o namedOverride
+	for n := name; n != ""; {
+		if l, ok := namedLevels.levels[n]; ok {
+			o = namedOverride{level: l, set: true}
+			break
+		}
+		dot := strings.LastIndexByte(n, '.')
+		if dot < 0 {
+			break
+		}
+		n = n[:dot]
+	}
+	// stored under the lock so a concurrent reset can not be undone
+	namedLevels.resolved.Store(name, o)
+	if o.set {
+		return o.level
+	}
+	return currentLevel()
+}
+
+// lowestLevel is the most verbose level any logger may log at
+func lowestLevel() zapcore.Level {
+	lowest := currentLevel()
+	if level, ok := lowestTargetLevel(); ok && levelRank(level) < levelRank(lowest) {
+		lowest = level
+	}
+	if namedLevels.count.Load() == 0 {
+		return lowest
+	}
+
+	namedLevels.mu.RLock()
+	defer namedLevels.mu.RUnlock()
+	for _, level := range namedLevels.levels {
+		if levelRank(level) < levelRank(lowest) {
+			lowest = level
+		}
+	}
+	return lowest
+}
+
+// newSinkLevelEnabler lets through every entry some logger may log, the
+// named level core then applies the level of each logger
+func newSinkLevelEnabler() zapcore.LevelEnabler {
+	return zap.LevelEnablerFunc(func(level zapcore.Level) bool {
+		return levelAtLeast(level, lowestLevel())
+	})
+}
+
+// namedLevelCore applies the effective level of the logger name of every
+// entry, entries below it are kept for Write when a DebugTarget may log them
+type namedLevelCore struct {
+	zapcore.Core
+	// context holds the fields added by With for the debug targets
+	context []zapcore.Field
+}
+
+func (c *namedLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	context := make([]zapcore.Field, 0, len(c.context)+len(fields))
+	context = append(append(context, c.context...), fields...)
+	return &namedLevelCore{Core: c.Core.With(fields), context: context}
+}
+
+func (c *namedLevelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if levelAtLeast(ent.Level, effectiveLevel(ent.LoggerName)) {
+		return c.Core.Check(ent, ce)
+	}
+	if level, ok := lowestTargetLevel(); ok && levelAtLeast(ent.Level, level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write only sees the entries below the logger level, see Check
+func (c *namedLevelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !targeted(ent.Level, c.context, fields) {
+		return nil
+	}
+	if ce := c.Core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap/zapcore"
+)
+
+// NATSConfig configures the NATS sink, entries are published as JSON
+type NATSConfig struct {
+	// URL of the NATS servers, comma separated, default nats.DefaultURL
+	URL string
+	// Subject is the subject template, {level}, {logger} and {<field>} are
+	// replaced per entry, e.g. "logs.{level}.{category}"
+	Subject string
+	// DefaultToken replaces placeholders without a value, default "none"
+	DefaultToken string
+	// JetStream publishes through JetStream so entries are persisted by a stream
+	JetStream bool
+	// FlushTimeout bounds Sync while waiting for acks and flushes, default 5s
+	FlushTimeout time.Duration
+	// TLS enables TLS when not nil
+	TLS *TLSConfig
+	// Options are passed to nats.Connect as is
+	Options []nats.Option
+}
+
+type natsWriter struct {
+	config NATSConfig
+	conn   *nats.Conn
+	js     nats.JetStreamContext
+}
+
+func newNATSWriter(config NATSConfig) (*natsWriter, error) {
+	if config.Subject == "" {
+		return nil, errors.New("Bad nats subject")
+	}
+	if config.URL == "" {
+		config.URL = nats.DefaultURL
+	}
+	if config.DefaultToken == "" {
+		config.DefaultToken = "none"
+	}
+	if config.FlushTimeout <= 0 {
+		config.FlushTimeout = 5 * time.Second
+	}
+
+	opts := append([]nats.Option{}, config.Options...)
+	if config.TLS != nil {
+		tlsConfig, err := config.TLS.Build()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, nats.Secure(tlsConfig))
+	}
+	// keep retrying forever, the client buffers while reconnecting
+	opts = append(opts, nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			internalLog(zapcore.WarnLevel, "Sink connection lost", String("sink", "nats"), String("address", config.URL), Err(err))
+		}),
+		nats.ReconnectHandler(func(*nats.Conn) {
+			internalLog(zapcore.InfoLevel, "Sink reconnected", String("sink", "nats"), String("address", config.URL))
+		}))
+
+	conn, err := nats.Connect(config.URL, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &natsWriter{config: config, conn: conn}
+	if config.JetStream {
+		if w.js, err = conn.JetStream(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+func (w *natsWriter) WriteEntry(ent zapcore.Entry, fields []zapcore.Field, encoded []byte) error {
+	subject := expandTemplate(w.config.Subject, ent, fieldMap(fields), w.config.DefaultToken)
+	data := append([]byte(nil), bytes.TrimRight(encoded, "\n")...)
+
+	if w.js != nil {
+		_, err := w.js.PublishAsync(subject, data)
+		return err
+	}
+	return w.conn.Publish(subject, data)
+}
+
+func (w *natsWriter) Sync() error {
+	if w.js != nil {
+		select {
+		case <-w.js.PublishAsyncComplete():
+		case <-time.After(w.config.FlushTimeout):
+			return errors.New("Timeout waiting for jetstream acks")
+		}
+	}
+	return w.conn.FlushTimeout(w.config.FlushTimeout)
+}
+
+func (w *natsWriter) Close() error {
+	return w.conn.Drain()
+}
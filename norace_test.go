@@ -0,0 +1,6 @@
+//go:build !race
+
+package logger
+
+// raceEnabled skips the allocation tests, the race detector allocates
+const raceEnabled = false
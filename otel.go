@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+)
+
+// SpanEventConfig mirrors the entries logged with Log.Ctx as events of
+// the active span, so traces show the log context inline
+type SpanEventConfig struct {
+	// Level is the lowest level mirrored, default warn
+	Level *zapcore.Level
+	// Fields lists the field keys added as event attributes, every field
+	// when empty
+	Fields []string
+}
+
+// BaggageConfig selects the baggage members logged as fields
+type BaggageConfig struct {
+	// Keys lists the members logged, every member when empty
+	Keys []string
+	// Prefix is prepended to the field keys, e.g. "baggage."
+	Prefix string
+}
+
+func newSpanEventProcess(config SpanEventConfig) processFunc {
+	min := zapcore.WarnLevel
+	if config.Level != nil {
+		min = *config.Level
+	}
+	return func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+		if !levelAtLeast(ent.Level, min) {
+			return ent, fields, true
+		}
+		ctx := entryContext(context, fields)
+		if ctx == nil {
+			return ent, fields, true
+		}
+		span := trace.SpanFromContext(ctx)
+		if !span.IsRecording() {
+			return ent, fields, true
+		}
+
+		values := fieldMap(context)
+		for k, v := range fieldMap(fields) {
+			values[k] = v
+		}
+		attrs := make([]attribute.KeyValue, 0, len(values)+1)
+		attrs = append(attrs, attribute.String("level", levelName(ent.Level)))
+		for k, v := range values {
+			if len(config.Fields) == 0 || contains(config.Fields, k) {
+				attrs = append(attrs, spanAttribute(k, v))
+			}
+		}
+		span.AddEvent(ent.Message, trace.WithTimestamp(ent.Time), trace.WithAttributes(attrs...))
+		return ent, fields, true
+	}
+}
+
+func newTraceURLProcess(tmpl string) processFunc {
+	return func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+		if !levelAtLeast(ent.Level, zapcore.ErrorLevel) {
+			return ent, fields, true
+		}
+		ctx := entryContext(context, fields)
+		if ctx == nil {
+			return ent, fields, true
+		}
+		sc := trace.SpanContextFromContext(ctx)
+		if !sc.IsValid() {
+			return ent, fields, true
+		}
+		url := strings.NewReplacer("{trace_id}", sc.TraceID().String(), "{span_id}", sc.SpanID().String()).Replace(tmpl)
+		return ent, append(fields, String("trace_url", url)), true
+	}
+}
+
+// newBaggageProcess adds the baggage members as fields, a field already
+// set by the entry wins
+func newBaggageProcess(config BaggageConfig) processFunc {
+	return func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+		ctx := entryContext(context, fields)
+		if ctx == nil {
+			return ent, fields, true
+		}
+		members := baggage.FromContext(ctx).Members()
+		for _, m := range members {
+			key := config.Prefix + m.Key()
+			if len(config.Keys) > 0 && !contains(config.Keys, m.Key()) || hasField(key, fields) || hasField(key, context) {
+				continue
+			}
+			fields = append(fields, String(key, m.Value()))
+		}
+		return ent, fields, true
+	}
+}
+
+// spanAttribute converts a field value rendered by fieldMap
+func spanAttribute(key string, v interface{}) attribute.KeyValue {
+	switch v := v.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case int32:
+		return attribute.Int64(key, int64(v))
+	case uint32:
+		return attribute.Int64(key, int64(v))
+	case float64:
+		return attribute.Float64(key, v)
+	case float32:
+		return attribute.Float64(key, float64(v))
+	case time.Duration:
+		return attribute.String(key, v.String())
+	case time.Time:
+		return attribute.String(key, v.Format(time.RFC3339Nano))
+	case []string:
+		return attribute.StringSlice(key, v)
+	}
+	return attribute.String(key, attributeString(v))
+}
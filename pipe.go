@@ -0,0 +1,258 @@
+package logger
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Pattern parses one line format, see Regex and Grok
+type Pattern struct {
+	re *regexp.Regexp
+	// types of the groups declared as %{SYNTAX:name:int} or :float
+	types map[string]string
+}
+
+// Parser turns the output lines of a child process into entries:
+//
+//	p := logger.Parser{Patterns: []logger.Pattern{
+//		logger.MustGrok(`%{TIMESTAMP_ISO8601:time} \[%{LOGLEVEL:level}\] %{GREEDYDATA:msg}`),
+//	}}
+//	err := logger.PipeCommand(exec.Command("nginx", "-g", "daemon off;"), p)
+//
+// The named groups of the first matching pattern become string fields, the
+// "msg" group is the message and the "level" group the level. Lines no
+// pattern matches are logged as is.
+type Parser struct {
+	// Patterns are tried in order
+	Patterns []Pattern
+	// Logger is the named logger of the entries, default the base name of
+	// the command
+	Logger string
+	// Level of the stdout lines without a level group, info by default
+	Level zapcore.Level
+	// StderrLevel of the stderr lines without a level group, warn when nil
+	StderrLevel *zapcore.Level
+}
+
+// Regex compiles a regular expression, its named groups become fields
+func Regex(expr string) (Pattern, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return Pattern{}, err
+	}
+	return Pattern{re: re}, nil
+}
+
+// grokPatterns are the SYNTAX names known by Grok
+var grokPatterns = map[string]string{
+	"WORD":              `\w+`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"INT":               `[+-]?\d+`,
+	"NUMBER":            `[+-]?(?:\d+(?:\.\d*)?|\.\d+)`,
+	"BASE16NUM":         `(?:0[xX])?[0-9A-Fa-f]+`,
+	"UUID":              `[0-9A-Fa-f]{8}-(?:[0-9A-Fa-f]{4}-){3}[0-9A-Fa-f]{12}`,
+	"IPV4":              `(?:\d{1,3}\.){3}\d{1,3}`,
+	"IPV6":              `[0-9A-Fa-f:]*:[0-9A-Fa-f:.]+`,
+	"IP":                `(?:(?:\d{1,3}\.){3}\d{1,3}|[0-9A-Fa-f:]*:[0-9A-Fa-f:.]+)`,
+	"HOSTNAME":          `[0-9A-Za-z][0-9A-Za-z.-]*`,
+	"PATH":              `(?:/[^\s/]*)+`,
+	"URIPATH":           `/[^\s?#]*`,
+	"QUOTEDSTRING":      `"(?:[^"\\]|\\.)*"`,
+	"LOGLEVEL":          `(?i:trace|debug|info|notice|warn(?:ing)?|error|err|crit(?:ical)?|alert|emerg|fatal|panic)`,
+	"TIMESTAMP_ISO8601": `\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`,
+	"HTTPDATE":          `\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}`,
+	"SYSLOGTIMESTAMP":   `\w{3} +\d{1,2} \d{2}:\d{2}:\d{2}`,
+}
+
+var grokPlaceholder = regexp.MustCompile(`%\{(\w+)(?::(\w+))?(?::(int|float))?\}`)
+
+// Grok compiles a grok expression, %{SYNTAX} matches a known pattern and
+// %{SYNTAX:name} captures it as the field name, %{INT:port:int} and
+// %{NUMBER:ms:float} log the field as a number. The rest is a regular
+// expression.
+func Grok(expr string) (Pattern, error) {
+	types := map[string]string{}
+	var unknown string
+	expanded := grokPlaceholder.ReplaceAllStringFunc(expr, func(m string) string {
+		parts := grokPlaceholder.FindStringSubmatch(m)
+		re, ok := grokPatterns[parts[1]]
+		if !ok {
+			unknown = parts[1]
+			return m
+		}
+		if parts[2] == "" {
+			return "(?:" + re + ")"
+		}
+		if parts[3] != "" {
+			types[parts[2]] = parts[3]
+		}
+		return "(?P<" + parts[2] + ">" + re + ")"
+	})
+	if unknown != "" {
+		return Pattern{}, errors.New("Bad grok pattern " + unknown)
+	}
+	p, err := Regex(expanded)
+	if err != nil {
+		return Pattern{}, err
+	}
+	p.types = types
+	return p, nil
+}
+
+// MustGrok is like Grok but panics on a bad expression
+func MustGrok(expr string) Pattern {
+	p, err := Grok(expr)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// grokLevels maps the level spellings of other programs
+var grokLevels = map[string]zapcore.Level{
+	"trace":   zapcore.DebugLevel,
+	"warning": zapcore.WarnLevel,
+	"err":     zapcore.ErrorLevel,
+	"crit":    CriticalLevel,
+	"alert":   CriticalLevel,
+	"emerg":   CriticalLevel,
+	// the child failing must not stop this process
+	"fatal": CriticalLevel,
+	"panic": CriticalLevel,
+}
+
+func parseLineLevel(name string) (zapcore.Level, bool) {
+	name = strings.ToLower(name)
+	if level, ok := grokLevels[name]; ok {
+		return level, true
+	}
+	level, err := parseLevel(name)
+	return level, err == nil
+}
+
+// parse returns the message, level and fields of line, level is only set
+// when a level group matched
+func (p Parser) parse(line string) (string, *zapcore.Level, []zapcore.Field) {
+	for _, pattern := range p.Patterns {
+		m := pattern.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		msg := line
+		var level *zapcore.Level
+		var fields []zapcore.Field
+		for i, name := range pattern.re.SubexpNames() {
+			if name == "" {
+				continue
+			}
+			switch name {
+			case "msg", "message":
+				msg = m[i]
+				continue
+			case "level":
+				if l, ok := parseLineLevel(m[i]); ok {
+					level = &l
+					continue
+				}
+			}
+			fields = append(fields, typedField(name, m[i], pattern.types[name]))
+		}
+		return msg, level, fields
+	}
+	return line, nil, nil
+}
+
+func typedField(key, value, typ string) zapcore.Field {
+	switch typ {
+	case "int":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return Int64(key, n)
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return zap.Float64(key, f)
+		}
+	}
+	return String(key, value)
+}
+
+// PipeCommand runs cmd and logs every line of its stdout and stderr
+// through parser, with a "stream" field set to stdout or stderr, and
+// returns once the command exited, with the error of cmd.Wait.
+// cmd.Stdout and cmd.Stderr must be nil.
+func PipeCommand(cmd *exec.Cmd, parser Parser) error {
+	if cmd.Stdout != nil || cmd.Stderr != nil {
+		return errors.New("Command output already set")
+	}
+	name := parser.Logger
+	if name == "" {
+		name = filepath.Base(cmd.Path)
+	}
+	log := Named(name)
+	stderrLevel := zapcore.WarnLevel
+	if parser.StderrLevel != nil {
+		stderrLevel = *parser.StderrLevel
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		pipeLines(log, parser, stdout, "stdout", parser.Level)
+	}()
+	go func() {
+		defer wg.Done()
+		pipeLines(log, parser, stderr, "stderr", stderrLevel)
+	}()
+	// Wait closes the pipes, the lines must be read first
+	wg.Wait()
+	return cmd.Wait()
+}
+
+// pipeLines logs the lines of r with extra appended to the fields
+func pipeLines(log *Log, parser Parser, r io.Reader, stream string, level zapcore.Level, extra ...zapcore.Field) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		msg, lineLevel, fields := parser.parse(line)
+		if lineLevel == nil {
+			lineLevel = &level
+		}
+		fields = append(fields, extra...)
+		log.Log(*lineLevel, msg, append(fields, String("stream", stream))...)
+	}
+	if err := scanner.Err(); err != nil {
+		internalLog(zapcore.ErrorLevel, "Failed read command output", String("logger", log.name), String("stream", stream), Err(err))
+		// keep the child from blocking on a full pipe
+		io.Copy(io.Discard, r)
+	}
+}
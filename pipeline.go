@@ -0,0 +1,194 @@
+package logger
+
+import (
+	"io"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// processFunc rewrites an entry before it reaches the sinks, context holds
+// the fields added by With, returning false drops the entry
+type processFunc func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool)
+
+// processCore runs process on every entry, then hands the result to the
+// wrapped core, which still applies the level of each of its sinks
+type processCore struct {
+	zapcore.Core
+	process processFunc
+	context []zapcore.Field
+	// owns keeps the context away from the wrapped core, process then
+	// returns the complete field list, context included
+	owns bool
+}
+
+func newProcessCore(core zapcore.Core, process processFunc) zapcore.Core {
+	return &processCore{Core: core, process: process}
+}
+
+// newContextProcessCore is a processCore that also rewrites the context,
+// at the cost of encoding the context again for every entry
+func newContextProcessCore(core zapcore.Core, process processFunc) zapcore.Core {
+	return &processCore{Core: core, process: process, owns: true}
+}
+
+func (c *processCore) With(fields []zapcore.Field) zapcore.Core {
+	context := make([]zapcore.Field, 0, len(c.context)+len(fields))
+	context = append(append(context, c.context...), fields...)
+	inner := c.Core
+	if !c.owns {
+		inner = inner.With(fields)
+	}
+	return &processCore{Core: inner, process: c.process, context: context, owns: c.owns}
+}
+
+func (c *processCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *processCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent, fields, ok := c.process(ent, c.context, fields)
+	if !ok {
+		return nil
+	}
+	if ce := c.Core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+// wrapCore applies the entry processors enabled in config, every processor
+// wraps the ones before it so the last one listed sees the entry first.
+// The closers release the processors holding goroutines.
+func wrapCore(core zapcore.Core, config Config) (zapcore.Core, []io.Closer) {
+	var closers []io.Closer
+	// the allowlist sees the fields added by every other processor
+	if len(config.AllowedFields) > 0 {
+		core = newContextProcessCore(core, newAllowlistProcess(config.AllowedFields, allowlistCounter(config)))
+	}
+	// enrichers run beneath the async queue, on its writer goroutine
+	if list := registeredEnrichers(); len(list) > 0 {
+		core = newProcessCore(core, newEnrichProcess(list))
+	}
+	var latency *writeLatency
+	if config.AdaptiveSampling != nil {
+		latency = &writeLatency{}
+		core = &latencyCore{Core: core, latency: latency}
+	}
+	var queueFill func() float64
+	if config.Async != nil || config.Engine == EngineRing {
+		async := AsyncConfig{}
+		if config.Async != nil {
+			async = *config.Async
+		}
+		c := newAsyncCore(core, async, config.Engine)
+		core = c
+		closers = append(closers, c)
+		queueFill = func() float64 {
+			return float64(c.q.queue.len()) / float64(c.q.queue.cap())
+		}
+	}
+	if config.CallerFunc {
+		core = newProcessCore(core, callerFuncProcess)
+	}
+	if config.ComponentField {
+		core = newProcessCore(core, newComponentResolver(config.ComponentNames).process)
+	}
+	if config.KeyCase != "" || config.KeyPrefix != "" || config.DuplicateKeys != "" {
+		core = newContextProcessCore(core, newKeyNormalizer(config.KeyCase, config.KeyPrefix, config.DuplicateKeys))
+	}
+	if config.SpanEvents != nil {
+		core = newProcessCore(core, newSpanEventProcess(*config.SpanEvents))
+	}
+	if config.TraceURL != "" {
+		core = newProcessCore(core, newTraceURLProcess(config.TraceURL))
+	}
+	if config.Datadog != nil {
+		core = newProcessCore(core, datadogTraceProcess)
+	}
+	if config.SourceLink != nil {
+		core = newProcessCore(core, newSourceLinkProcess(*config.SourceLink))
+	}
+	if config.Baggage != nil {
+		core = newProcessCore(core, newBaggageProcess(*config.Baggage))
+	}
+	if config.Multiline != "" {
+		core = newProcessCore(core, newMultilineProcess(config.Multiline))
+	}
+	if config.BinaryEncoding != "" {
+		core = newProcessCore(core, newUTF8Process(config.BinaryEncoding))
+	}
+	if config.SchemaValidation {
+		core = newProcessCore(core, newSchemaValidator(config.SchemaViolationHandler))
+	}
+	if config.AdaptiveSampling != nil {
+		s := newAdaptiveSampler(*config.AdaptiveSampling, latency, queueFill)
+		closers = append(closers, s)
+		core = newProcessCore(core, s.process)
+	}
+	if config.Sampling != nil {
+		s := newSampler(*config.Sampling)
+		if config.Sampling.ReportInterval > 0 {
+			s.startReport(core)
+			// the last report goes through the async queue, close it first
+			closers = append([]io.Closer{s}, closers...)
+		}
+		core = newProcessCore(core, s.process)
+	}
+	// capture sessions start at any time, they see the transformed entries
+	core = newProcessCore(core, newCaptureProcess())
+	if config.RecentEntries > 0 {
+		r := newRecentEntries(config, recent.Load())
+		recent.Store(r)
+		core = newProcessCore(core, r.process)
+	} else {
+		recent.Store(nil)
+	}
+	if pipeline := registeredTransformers(); len(pipeline) > 0 {
+		core = newContextProcessCore(core, newTransformProcess(pipeline))
+	}
+	if config.DiskGuard != nil {
+		core = newProcessCore(core, diskGuardProcess)
+	}
+	if len(config.Filters) > 0 {
+		core = newProcessCore(core, newFilterProcess(config.Filters))
+	}
+	if config.MetricsRegisterer != nil {
+		core = newProcessCore(core, newMetricsCollector(config.MetricsRegisterer, config.MetricsNamespace).process)
+	}
+	if config.StatsD != nil {
+		if s, err := newStatsDEmitter(*config.StatsD); err != nil {
+			internalLog(zapcore.ErrorLevel, "Failed create statsd emitter", String("address", config.StatsD.Address), Err(err))
+		} else {
+			closers = append(closers, s)
+			core = newProcessCore(core, s.process)
+		}
+	}
+	if config.WideEvents != nil {
+		if s, err := newHoneycombSender(*config.WideEvents); err != nil {
+			internalLog(zapcore.ErrorLevel, "Failed create wide event sender", String("dataset", config.WideEvents.Dataset), Err(err))
+			wideSender.Store(nil)
+		} else {
+			wideSender.Store(s)
+			closers = append(closers, s)
+			core = newProcessCore(core, wideEventProcess)
+		}
+	} else {
+		wideSender.Store(nil)
+	}
+	// the SLO counts the entries sampling and filters drop
+	if config.SLO != nil {
+		core = newProcessCore(core, newSLOProcess(*config.SLO))
+	}
+	// the addresses are truncated before any processor sees them
+	if config.IPTruncation != nil {
+		core = newContextProcessCore(core, newIPTruncateProcess(*config.IPTruncation))
+	}
+	// the goroutine is only known before the async queue
+	if config.GoroutineID {
+		core = newProcessCore(core, goroutineProcess)
+	}
+	return &namedLevelCore{Core: core}, closers
+}
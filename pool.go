@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// maxPooledFields bounds the slices kept by fieldPool, bigger ones are left
+// to the GC so a single huge entry does not pin its memory
+const maxPooledFields = 64
+
+// fieldPool recycles the field slices built when an entry needs fields
+// appended, e.g. the stack of Debug or the error of IfError
+var fieldPool = sync.Pool{
+	New: func() interface{} {
+		fields := make([]zapcore.Field, 0, 16)
+		return &fields
+	},
+}
+
+// getFields returns an empty pooled slice holding fields and room for extra more
+func getFields(fields []zapcore.Field, extra int) *[]zapcore.Field {
+	p := fieldPool.Get().(*[]zapcore.Field)
+	if cap(*p) < len(fields)+extra {
+		*p = make([]zapcore.Field, 0, len(fields)+extra)
+	}
+	*p = append(*p, fields...)
+	return p
+}
+
+// putFields returns p to the pool, the slice must not be used afterwards
+func putFields(p *[]zapcore.Field) {
+	if cap(*p) > maxPooledFields {
+		return
+	}
+	for i := range *p {
+		(*p)[i] = zapcore.Field{}
+	}
+	*p = (*p)[:0]
+	fieldPool.Put(p)
+}
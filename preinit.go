@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"os"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// preInitCore fronts the logger used until Configure runs, the first entry
+// written through it is preceded by a warning so the early output is noticed
+type preInitCore struct {
+	zapcore.Core
+	once *sync.Once
+}
+
+// newPreInitLogger writes console entries at info and above to stderr
+func newPreInitLogger() *zap.Logger {
+	core := zapcore.NewCore(newEncoder(Config{}, EncodingConsole), zapcore.Lock(os.Stderr), zapcore.InfoLevel)
+	return zap.New(&preInitCore{Core: core, once: new(sync.Once)}, zapOptions(Config{})...)
+}
+
+func (c *preInitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &preInitCore{Core: c.Core.With(fields), once: c.once}
+}
+
+func (c *preInitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *preInitCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	c.once.Do(func() {
+		c.Core.Write(zapcore.Entry{
+			Level:      zapcore.WarnLevel,
+			Time:       ent.Time,
+			LoggerName: InternalLoggerName,
+			Message:    "Logging before Configure or Init, writing to stderr",
+		}, nil)
+	})
+	return c.Core.Write(ent, fields)
+}
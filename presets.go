@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// DevelopmentConfig is the Config of Development, colored console output
+// on stdout with the caller and a stack trace from warn up, the colors are
+// left out when stdout is not a terminal
+func DevelopmentConfig() Config {
+	stacktrace := zapcore.WarnLevel
+	return Config{
+		Encoding:   EncodingConsole,
+		Caller:     true,
+		Stacktrace: &stacktrace,
+		LevelColors: map[zapcore.Level]string{
+			zapcore.DebugLevel:  "magenta",
+			zapcore.InfoLevel:   "green",
+			NoticeLevel:         "cyan",
+			zapcore.WarnLevel:   "yellow",
+			zapcore.ErrorLevel:  "red",
+			CriticalLevel:       "red",
+			zapcore.DPanicLevel: "red",
+			zapcore.PanicLevel:  "red",
+			zapcore.FatalLevel:  "red",
+		},
+	}
+}
+
+// ProductionConfig is the Config of Production, JSON entries on stdout and
+// in file, rolled at 100MB with 10 backups kept for 30 days, repeated
+// entries sampled past the first 100 per second with every 100th kept
+func ProductionConfig(file string) (Config, error) {
+	dir, name, err := splitLogFile(file)
+	if err != nil {
+		return Config{}, err
+	}
+	return Config{
+		Encoding:           EncodingJSON,
+		FileLoggingEnabled: true,
+		Directory:          dir,
+		Filename:           name,
+		MaxSize:            100,
+		MaxBackups:         10,
+		MaxAge:             30,
+		Sampling:           &SamplingConfig{First: 100, Thereafter: 100},
+	}, nil
+}
+
+// Development configures the package for local work, DevelopmentConfig at
+// the debug level:
+//
+//	log := logger.Development()
+func Development() Log {
+	SetLogLevel("debug")
+	Configure(DevelopmentConfig())
+	return Log{}
+}
+
+// Production configures the package for deployment, ProductionConfig at
+// the info level:
+//
+//	log, err := logger.Production("/var/log/app/app.log")
+func Production(file string) (Log, error) {
+	config, err := ProductionConfig(file)
+	if err != nil {
+		return Log{}, err
+	}
+	SetLogLevel("info")
+	Configure(config)
+	return Log{}, nil
+}
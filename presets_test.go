@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestDevelopmentColors(t *testing.T) {
+	config := DevelopmentConfig()
+	ent := zapcore.Entry{Level: zapcore.WarnLevel, Time: time.Now(), Message: "Slow"}
+
+	// a terminal
+	buf, err := newColorEncoder(config, config.encoding(), true).EncodeEntry(ent, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, colorYellow+"warn"+colorReset) {
+		t.Errorf("no colored level in %q", out)
+	}
+
+	// a pipe or a file
+	buf, err = newColorEncoder(config, config.encoding(), false).EncodeEntry(ent, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); strings.Contains(out, "\x1b[") {
+		t.Errorf("colors written without terminal: %q", out)
+	}
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"encoding/json"
+	"sort"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// EncodingProtobuf writes length delimited Entry messages as defined in entry.proto
+const EncodingProtobuf = "protobuf"
+
+// field numbers of the Entry message in entry.proto
+const (
+	protoTimestamp  protowire.Number = 1
+	protoLevel      protowire.Number = 2
+	protoMessage    protowire.Number = 3
+	protoAttributes protowire.Number = 4
+	protoLogger     protowire.Number = 5
+	protoCaller     protowire.Number = 6
+	protoStack      protowire.Number = 7
+)
+
+type protoEncoder struct {
+	*mapEncoder
+}
+
+func newProtoEncoder(cfg zapcore.EncoderConfig) *protoEncoder {
+	return &protoEncoder{mapEncoder: newMapEncoder(cfg, nil)}
+}
+
+func (e *protoEncoder) Clone() zapcore.Encoder {
+	return &protoEncoder{mapEncoder: e.mapEncoder.Clone().(*mapEncoder)}
+}
+
+func appendProtoString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func attributeString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+func (e *protoEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	attrs := make(map[string]interface{}, len(e.Fields)+len(fields))
+	for k, v := range e.Fields {
+		attrs[k] = v
+	}
+	for k, v := range fieldMap(fields) {
+		attrs[k] = v
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var msg []byte
+	msg = protowire.AppendTag(msg, protoTimestamp, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, uint64(ent.Time.UnixNano()))
+	msg = appendProtoString(msg, protoLevel, levelName(ent.Level))
+	msg = appendProtoString(msg, protoMessage, ent.Message)
+	for _, k := range keys {
+		var kv []byte
+		kv = appendProtoString(kv, 1, k)
+		kv = appendProtoString(kv, 2, attributeString(attrs[k]))
+		msg = protowire.AppendTag(msg, protoAttributes, protowire.BytesType)
+		msg = protowire.AppendBytes(msg, kv)
+	}
+	msg = appendProtoString(msg, protoLogger, ent.LoggerName)
+	if ent.Caller.Defined {
+		msg = appendProtoString(msg, protoCaller, ent.Caller.TrimmedPath())
+	}
+	msg = appendProtoString(msg, protoStack, ent.Stack)
+
+	buf := bufferPool.Get()
+	buf.Write(protowire.AppendVarint(nil, uint64(len(msg))))
+	buf.Write(msg)
+	return buf, nil
+}
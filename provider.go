@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LevelProvider supplies RemoteSettings from an external source such as
+// a feature flag service, see LaunchDarklyLevels and UnleashLevels
+type LevelProvider interface {
+	// Settings returns the settings to apply, nil keeps the current ones
+	Settings() (*RemoteSettings, error)
+	// Changes signals that Settings may have changed, nil when the
+	// provider is only polled
+	Changes() <-chan struct{}
+}
+
+// WatchLevelProvider applies the settings of p every interval (30s when
+// 0) and whenever p signals a change, until Close. Settings failing to
+// parse or to load keep the last known good ones in effect.
+func WatchLevelProvider(p LevelProvider, interval time.Duration) *RemoteController {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	c := newRemoteController("provider", fmt.Sprintf("%T", p))
+	go func() {
+		defer close(c.done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			if settings, err := p.Settings(); err != nil {
+				c.failed("Failed read level provider", err)
+			} else if settings != nil {
+				data, _ := json.Marshal(settings)
+				c.apply(data)
+			}
+
+			select {
+			case <-c.stop:
+				return
+			case <-t.C:
+			case <-p.Changes():
+			}
+		}
+	}()
+	return c
+}
+
+// flagSettings reads a flag value, either a level name or a RemoteSettings
+// JSON document
+func flagSettings(value string) (*RemoteSettings, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(value, "{") {
+		return &RemoteSettings{Level: value}, nil
+	}
+	settings := new(RemoteSettings)
+	if err := json.Unmarshal([]byte(value), settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
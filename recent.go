@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// recentEntries keeps the last entries logged as JSON lines, see
+// Config.RecentEntries
+type recentEntries struct {
+	enc   zapcore.Encoder
+	mu    sync.Mutex
+	lines [][]byte
+	next  int
+	full  bool
+}
+
+var recent atomic.Pointer[recentEntries]
+
+// newRecentEntries carries the entries of old over, the ones that fit
+func newRecentEntries(config Config, old *recentEntries) *recentEntries {
+	r := &recentEntries{
+		enc:   zapcore.NewJSONEncoder(newEncoderConfig(config)),
+		lines: make([][]byte, config.RecentEntries),
+	}
+	if old != nil {
+		for _, line := range old.snapshot() {
+			r.push(line)
+		}
+	}
+	return r
+}
+
+func (r *recentEntries) process(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+	all := fields
+	if len(context) > 0 {
+		all = make([]zapcore.Field, 0, len(context)+len(fields))
+		all = append(append(all, context...), fields...)
+	}
+	buf, err := r.enc.EncodeEntry(ent, all)
+	if err != nil {
+		return ent, fields, true
+	}
+
+	r.mu.Lock()
+	r.push(buf.Bytes())
+	r.mu.Unlock()
+	buf.Free()
+	return ent, fields, true
+}
+
+// push copies line into the slot of the oldest entry, r.mu must be held
+// once r is shared
+func (r *recentEntries) push(line []byte) {
+	r.lines[r.next] = append(r.lines[r.next][:0], line...)
+	r.next++
+	if r.next == len(r.lines) {
+		r.next, r.full = 0, true
+	}
+}
+
+// snapshot returns the entries oldest first
+func (r *recentEntries) snapshot() [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var lines [][]byte
+	if r.full {
+		lines = append(lines, r.lines[r.next:]...)
+	}
+	lines = append(lines, r.lines[:r.next]...)
+	out := make([][]byte, len(lines))
+	for i, l := range lines {
+		out[i] = append([]byte(nil), l...)
+	}
+	return out
+}
+
+// RecentEntries returns the last Config.RecentEntries entries logged,
+// oldest first, one JSON object per line
+func RecentEntries() [][]byte {
+	r := recent.Load()
+	if r == nil {
+		return nil
+	}
+	return r.snapshot()
+}
@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap/zapcore"
+)
+
+// RedisLogConfig configures NewRedisHook
+type RedisLogConfig struct {
+	// Log receives the entries, default Named("redis")
+	Log *Log
+	// Level of the commands, default debug
+	Level zapcore.Level
+	// SlowThreshold logs the commands taking longer at the warn level,
+	// disabled when 0
+	SlowThreshold time.Duration
+	// RedactKeyPrefixes hides the rest of the keys starting with one of
+	// these prefixes, "session:" logs session:8c1f as session:***
+	RedactKeyPrefixes []string
+	// Sample logs one in n successful commands of a name, e.g.
+	// {"get": 100}, errors and slow commands are always logged
+	Sample map[string]int
+}
+
+// NewRedisHook returns a go-redis hook logging the commands with their key,
+// duration and error, and the pipelines with their size. Values are never
+// logged. redis.Nil is not an error.
+//
+//	rdb.AddHook(logger.NewRedisHook(logger.RedisLogConfig{SlowThreshold: 50 * time.Millisecond}))
+func NewRedisHook(config RedisLogConfig) redis.Hook {
+	if config.Log == nil {
+		config.Log = Named("redis")
+	}
+	sample := make(map[string]int, len(config.Sample))
+	for name, n := range config.Sample {
+		sample[strings.ToLower(name)] = n
+	}
+	config.Sample = sample
+	return &redisHook{config: config, counts: make(map[string]uint64)}
+}
+
+type redisHook struct {
+	config RedisLogConfig
+	mu     sync.Mutex
+	// counts numbers the successful commands of the sampled names
+	counts map[string]uint64
+}
+
+func (h *redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			h.config.Log.Ctx(ctx).Error("Redis dial failed", String("address", addr), Err(err))
+		}
+		return conn, err
+	}
+}
+
+func (h *redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.log(ctx, "Redis "+cmd.Name(), time.Since(start), cmd.Err(), []redis.Cmder{cmd})
+		return err
+	}
+}
+
+func (h *redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.log(ctx, "Redis pipeline", time.Since(start), err, cmds)
+		return err
+	}
+}
+
+// sampled reports whether a successful single command is skipped
+func (h *redisHook) sampled(name string) bool {
+	n := h.config.Sample[name]
+	if n <= 1 {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[name]++
+	return h.counts[name]%uint64(n) != 1
+}
+
+func (h *redisHook) log(ctx context.Context, msg string, elapsed time.Duration, err error, cmds []redis.Cmder) {
+	if errors.Is(err, redis.Nil) {
+		err = nil
+	}
+	level := h.config.Level
+	slow := h.config.SlowThreshold > 0 && elapsed >= h.config.SlowThreshold
+	switch {
+	case err != nil:
+		level, msg = zapcore.ErrorLevel, msg+" failed"
+	case slow:
+		level, msg = zapcore.WarnLevel, "Slow "+msg
+	case len(cmds) == 1 && h.sampled(cmds[0].Name()):
+		return
+	}
+	log := h.config.Log.Ctx(ctx)
+	ce := log.zap().Check(level, msg)
+	if ce == nil {
+		return
+	}
+
+	fields := []zapcore.Field{Duration("elapsed", elapsed)}
+	if len(cmds) == 1 {
+		if key := h.key(cmds[0]); key != "" {
+			fields = append(fields, String("key", key))
+		}
+	} else {
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = cmd.Name()
+		}
+		fields = append(fields, Int("commands", len(cmds)), Strings("names", names))
+	}
+	if err != nil {
+		fields = append(fields, Err(err))
+	}
+	log.write(ce, fields)
+}
+
+// key returns the first argument of cmd, redacted
+func (h *redisHook) key(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) < 2 {
+		return ""
+	}
+	key := fmt.Sprint(args[1])
+	for _, prefix := range h.config.RedactKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return prefix + "***"
+		}
+	}
+	return key
+}
@@ -0,0 +1,189 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RemoteSettings is the JSON document read by the remote controllers, e.g.
+// under /config/logging/<service>:
+//
+//	{"level": "debug", "named_levels": {"svc.db": "debug"},
+//	 "sampling": {"tick": "1s", "first": 100, "thereafter": 10}}
+//
+// Level and Sampling keep their current value when missing, named levels
+// set by an earlier document and missing from the new one are cleared
+type RemoteSettings struct {
+	Level       string            `json:"level"`
+	NamedLevels map[string]string `json:"named_levels"`
+	Sampling    *RemoteSampling   `json:"sampling"`
+}
+
+// RemoteSampling is the sampling member of RemoteSettings, see SamplingConfig
+type RemoteSampling struct {
+	// Off disables sampling
+	Off        bool   `json:"off"`
+	Tick       string `json:"tick"`
+	First      int    `json:"first"`
+	Thereafter int    `json:"thereafter"`
+	RateLimit  int    `json:"rate_limit"`
+}
+
+// remoteRetryInterval is the wait before a failed watch is restarted
+const remoteRetryInterval = 5 * time.Second
+
+// RemoteController applies the RemoteSettings stored under a key of a
+// config store. A document that does not parse is ignored and the last
+// known good settings stay in effect, so does a deleted key.
+type RemoteController struct {
+	source string
+	key    string
+
+	mu    sync.Mutex
+	good  []byte
+	named map[string]bool
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func newRemoteController(source, key string) *RemoteController {
+	return &RemoteController{
+		source: source,
+		key:    key,
+		named:  make(map[string]bool),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// LastKnownGood returns the document in effect, nil before the first one
+func (c *RemoteController) LastKnownGood() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.good
+}
+
+// Close stops watching, the settings applied stay in effect
+func (c *RemoteController) Close() error {
+	c.once.Do(func() {
+		close(c.stop)
+	})
+	<-c.done
+	return nil
+}
+
+// wait sleeps for d, it returns false once the controller is closed
+func (c *RemoteController) wait(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-c.stop:
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+func (c *RemoteController) failed(msg string, err error) {
+	internalLog(zapcore.WarnLevel, msg, String("source", c.source), String("key", c.key), Err(err))
+}
+
+// deleted keeps the last known good settings when the key goes away
+func (c *RemoteController) deleted() {
+	internalLog(zapcore.WarnLevel, "Remote logging config deleted, keeping last known good",
+		String("source", c.source), String("key", c.key))
+}
+
+// apply parses data and applies it, nothing changes when any part is bad
+func (c *RemoteController) apply(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.good != nil && bytes.Equal(data, c.good) {
+		return
+	}
+	var settings RemoteSettings
+	err := json.Unmarshal(data, &settings)
+	var sampling *SamplingConfig
+	if err == nil {
+		sampling, err = settings.check()
+	}
+	if err != nil {
+		internalLog(zapcore.ErrorLevel, "Bad remote logging config, keeping last known good",
+			String("source", c.source), String("key", c.key), Err(err))
+		return
+	}
+
+	if settings.Level != "" {
+		if err := SetLogLevel(settings.Level); err != nil {
+			c.failed("Failed apply remote logging config", err)
+			return
+		}
+	}
+	for name := range c.named {
+		if _, ok := settings.NamedLevels[name]; !ok {
+			ClearNamedLevel(name)
+			delete(c.named, name)
+		}
+	}
+	for name, level := range settings.NamedLevels {
+		if err := SetNamedLevel(name, level); err != nil {
+			c.failed("Failed apply remote logging config", err)
+			return
+		}
+		c.named[name] = true
+	}
+	if config := *currentConfig(); settings.Sampling != nil && !reflect.DeepEqual(config.Sampling, sampling) {
+		// sampling is part of the pipeline, it takes a new Configure,
+		// which must keep the level just set
+		config.Sampling = sampling
+		config.LogLevel, config.LevelSet = currentLevel(), true
+		Configure(config)
+	}
+
+	c.good = append([]byte(nil), data...)
+	internalLog(zapcore.InfoLevel, "Remote logging config applied",
+		String("source", c.source), String("key", c.key))
+}
+
+// check validates every member, it returns the sampling to configure
+func (s RemoteSettings) check() (*SamplingConfig, error) {
+	if s.Level != "" {
+		if _, err := parseRootLevel(s.Level); err != nil {
+			return nil, err
+		}
+	}
+	for _, level := range s.NamedLevels {
+		if _, err := parseLevel(level); err != nil {
+			return nil, err
+		}
+	}
+	if s.Sampling == nil || s.Sampling.Off {
+		return nil, nil
+	}
+
+	sampling := &SamplingConfig{
+		First:      s.Sampling.First,
+		Thereafter: s.Sampling.Thereafter,
+		RateLimit:  s.Sampling.RateLimit,
+	}
+	if s.Sampling.Tick != "" {
+		tick, err := time.ParseDuration(s.Sampling.Tick)
+		if err != nil {
+			return nil, err
+		}
+		sampling.Tick = tick
+	}
+	if sampling.Tick < 0 || sampling.First < 0 || sampling.Thereafter < 0 || sampling.RateLimit < 0 {
+		return nil, errors.New("Bad sampling")
+	}
+	return sampling, nil
+}
@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRemoteLevelWithSampling(t *testing.T) {
+	old := *currentConfig()
+	t.Cleanup(func() { Configure(old) })
+	if err := SetLogLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newRemoteController("test", "logging")
+	c.apply([]byte(`{"level":"warn","sampling":{"first":10}}`))
+
+	if got := currentLevel(); got != zapcore.WarnLevel {
+		t.Errorf("level %s, want warn", got)
+	}
+	if s := currentConfig().Sampling; s == nil || s.First != 10 {
+		t.Errorf("sampling %+v, want first 10", s)
+	}
+}
+
+func TestRemoteRejectsPanicLevels(t *testing.T) {
+	for _, level := range []string{"dpanic", "panic", "fatal"} {
+		c := newRemoteController("test", "logging")
+		c.apply([]byte(`{"level":"` + level + `"}`))
+		if good := c.LastKnownGood(); good != nil {
+			t.Errorf("%s: document kept as last known good", level)
+		}
+	}
+}
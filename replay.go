@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gwtony/logger/logreader"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Replay writes the entries of src into sink, e.g. to backfill a sink
+// after an outage, and returns the number written. The entries keep their
+// time, speed scales the delay between them: 1 replays in real time, 10
+// ten times faster and 0 without any delay. Every entry is written,
+// whatever the configured levels.
+//
+//	f, _ := os.Open("/var/log/app/app-2024-05-01T10-00-00.000.log")
+//	n, err := logger.Replay(logreader.NewReader(f), logger.SinkConfig{Type: logger.SinkSocket, Socket: &socket}, 0)
+func Replay(src *logreader.Reader, sink SinkConfig, speed float64) (int, error) {
+	core, closer, err := newSinkCore(*currentConfig(), sink)
+	if err != nil {
+		return 0, err
+	}
+	if closer != nil {
+		defer closer.Close()
+	}
+
+	var first time.Time
+	start := time.Now()
+	n := 0
+	for src.Next() {
+		e := src.Entry()
+		if speed > 0 && !e.Time.IsZero() {
+			if first.IsZero() {
+				first = e.Time
+			}
+			due := start.Add(time.Duration(float64(e.Time.Sub(first)) / speed))
+			if wait := time.Until(due); wait > 0 {
+				time.Sleep(wait)
+			}
+		}
+
+		ent, fields := replayEntry(e)
+		if err := core.Write(ent, fields); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := core.Sync(); err != nil {
+		return n, err
+	}
+	return n, src.Err()
+}
+
+// replayEntry converts a parsed entry back, unknown levels become info
+func replayEntry(e logreader.Entry) (zapcore.Entry, []zapcore.Field) {
+	level, err := parseLevel(e.Level)
+	if err != nil {
+		level = zapcore.InfoLevel
+	}
+	ent := zapcore.Entry{
+		Level:      level,
+		Time:       e.Time,
+		LoggerName: e.Logger,
+		Message:    e.Message,
+		Stack:      e.Stack,
+	}
+	if ent.Time.IsZero() {
+		ent.Time = time.Now()
+	}
+	if colon := strings.LastIndexByte(e.Caller, ':'); colon > 0 {
+		if line, err := strconv.Atoi(e.Caller[colon+1:]); err == nil {
+			ent.Caller = zapcore.EntryCaller{Defined: true, File: e.Caller[:colon], Line: line}
+		}
+	}
+
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]zapcore.Field, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, zap.Any(k, e.Fields[k]))
+	}
+	return ent, fields
+}
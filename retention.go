@@ -0,0 +1,200 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RetentionConfig deletes the rotated logfiles at deterministic times,
+// in place of MaxBackups and MaxAge which lumberjack applies in 24 hour
+// steps from the rotation, shifting by an hour across DST:
+//
+//	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+//	Retention: &logger.RetentionConfig{Days: 14, Location: shanghai}
+type RetentionConfig struct {
+	// Days keeps the files rotated during the last Days calendar days,
+	// today included, a file is deleted at the midnight ending its last
+	// day, 0 keeps them whatever their age
+	Days int
+	// Location sets the midnight of Days, time.Local when nil
+	Location *time.Location
+	// Rotations keeps at most this many rotated files per logfile, the
+	// newest ones, 0 keeps them all
+	Rotations int
+	// CheckInterval is how often Rotations is applied, default 1 minute,
+	// Days is applied at every midnight
+	CheckInterval time.Duration
+}
+
+func (c RetentionConfig) withDefaults() RetentionConfig {
+	if c.Location == nil {
+		c.Location = time.Local
+	}
+	if c.CheckInterval <= 0 {
+		c.CheckInterval = time.Minute
+	}
+	return c
+}
+
+// RetainedFile is a rotated logfile and when the retention deletes it
+type RetainedFile struct {
+	Path string
+	// Rotated is the time in the file name
+	Rotated time.Time
+	// DeleteAt is when the file goes, zero when it is kept for good
+	DeleteAt time.Time
+	// Reason is "age" or "rotations", empty when kept for good
+	Reason string
+}
+
+// backupTimeFormat is the time lumberjack puts in the rotated file names
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RetentionPlan lists the rotated files of the logfile of config, oldest
+// first, with the time the retention deletes them as seen at now. Nothing
+// is deleted, the files due at now are those with DeleteAt not after now.
+func (c Config) RetentionPlan(now time.Time) ([]RetainedFile, error) {
+	if c.Retention == nil {
+		return nil, errors.New("No retention configured")
+	}
+	if !c.FileLoggingEnabled || c.Filename == "" {
+		return nil, errors.New("No logfile configured")
+	}
+	retention := c.Retention.withDefaults()
+	dir := c.Directory
+	if dir == "" {
+		dir = "."
+	}
+
+	var plan []RetainedFile
+	for _, name := range retainedLogfiles(c) {
+		files, err := rotatedFiles(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		for i := range files {
+			f := &files[i]
+			if retention.Days > 0 {
+				day := f.Rotated.In(retention.Location)
+				// time.Date normalizes the day, midnight stays midnight across DST
+				f.DeleteAt = time.Date(day.Year(), day.Month(), day.Day()+retention.Days, 0, 0, 0, 0, retention.Location)
+				f.Reason = "age"
+			}
+			if retention.Rotations > 0 && len(files)-i > retention.Rotations && (f.DeleteAt.IsZero() || f.DeleteAt.After(now)) {
+				f.DeleteAt, f.Reason = now, "rotations"
+			}
+		}
+		plan = append(plan, files...)
+	}
+	sort.Slice(plan, func(i, j int) bool { return plan[i].Rotated.Before(plan[j].Rotated) })
+	return plan, nil
+}
+
+// retainedLogfiles are the names of the logfiles, one per shard
+func retainedLogfiles(c Config) []string {
+	if c.FileShards <= 1 {
+		return []string{c.Filename}
+	}
+	names := make([]string, c.FileShards)
+	for i := range names {
+		names[i] = shardPath(c.Filename, i)
+	}
+	return names
+}
+
+// rotatedFiles lists the rotated files of name, oldest first, compressed
+// ones included
+func rotatedFiles(dir, name string) ([]RetainedFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	ext := filepath.Ext(name)
+	prefix := strings.TrimSuffix(name, ext) + "-"
+
+	var files []RetainedFile
+	for _, e := range entries {
+		base := strings.TrimSuffix(e.Name(), ".gz")
+		if e.IsDir() || !strings.HasPrefix(base, prefix) || !strings.HasSuffix(base, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(base, prefix), ext)
+		rotated, err := time.ParseInLocation(backupTimeFormat, stamp, time.UTC)
+		if err != nil {
+			continue
+		}
+		files = append(files, RetainedFile{Path: filepath.Join(dir, e.Name()), Rotated: rotated})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Rotated.Before(files[j].Rotated) })
+	return files, nil
+}
+
+// applyRetention deletes the files due at now
+func applyRetention(config Config, now time.Time) {
+	plan, err := config.RetentionPlan(now)
+	if err != nil {
+		internalLog(zapcore.WarnLevel, "Failed list rotated log files", Err(err))
+		return
+	}
+	for _, f := range plan {
+		if f.DeleteAt.IsZero() || f.DeleteAt.After(now) {
+			continue
+		}
+		if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+			internalLog(zapcore.WarnLevel, "Failed delete rotated log file", String("file", f.Path), Err(err))
+			continue
+		}
+		internalLog(zapcore.InfoLevel, "Rotated log file deleted", String("file", f.Path), String("reason", f.Reason))
+	}
+}
+
+var (
+	retentionMu   sync.Mutex
+	retentionStop chan struct{}
+)
+
+// startRetention replaces the running retention, applied right away, then
+// at every midnight of its location and every check interval
+func startRetention(config Config) {
+	retentionMu.Lock()
+	defer retentionMu.Unlock()
+
+	if retentionStop != nil {
+		close(retentionStop)
+		retentionStop = nil
+	}
+	if config.Retention == nil || !config.FileLoggingEnabled {
+		return
+	}
+
+	retention := config.Retention.withDefaults()
+	stop := make(chan struct{})
+	retentionStop = stop
+	go func() {
+		for {
+			now := time.Now()
+			applyRetention(config, now)
+
+			wait := retention.CheckInterval
+			day := now.In(retention.Location)
+			midnight := time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, retention.Location)
+			if until := midnight.Sub(now); retention.Days > 0 && until < wait {
+				wait = until
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-stop:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"sync/atomic"
+)
+
+// EngineRing selects the lock-free ring buffer for the async queue, for
+// producers logging millions of entries per second where the channel
+// becomes the bottleneck
+const EngineRing = "ring"
+
+type ringSlot struct {
+	// seq is the position the slot is writable at, position+1 once filled
+	seq atomic.Uint64
+	e   asyncEntry
+}
+
+// ringQueue is a bounded multi-producer single-consumer queue, producers
+// claim a position with a CAS and publish the slot through its sequence
+type ringQueue struct {
+	slots []ringSlot
+	mask  uint64
+	_     [56]byte
+	head  atomic.Uint64
+	_     [56]byte
+	// tail is only written by the consumer
+	tail atomic.Uint64
+}
+
+// newRingQueue rounds size up to a power of two
+func newRingQueue(size int) *ringQueue {
+	n := 1
+	for n < size {
+		n <<= 1
+	}
+	q := &ringQueue{slots: make([]ringSlot, n), mask: uint64(n - 1)}
+	for i := range q.slots {
+		q.slots[i].seq.Store(uint64(i))
+	}
+	return q
+}
+
+func (q *ringQueue) push(e asyncEntry) bool {
+	pos := q.head.Load()
+	for {
+		slot := &q.slots[pos&q.mask]
+		switch seq := slot.seq.Load(); {
+		case seq == pos:
+			if q.head.CompareAndSwap(pos, pos+1) {
+				slot.e = e
+				slot.seq.Store(pos + 1)
+				return true
+			}
+		case seq < pos:
+			// the consumer has not freed the slot yet, the ring is full
+			return false
+		}
+		pos = q.head.Load()
+	}
+}
+
+func (q *ringQueue) popBatch(dst []asyncEntry) []asyncEntry {
+	tail := q.tail.Load()
+	for len(dst) < cap(dst) {
+		slot := &q.slots[tail&q.mask]
+		if slot.seq.Load() != tail+1 {
+			break
+		}
+		dst = append(dst, slot.e)
+		slot.e = asyncEntry{}
+		slot.seq.Store(tail + q.mask + 1)
+		tail++
+	}
+	q.tail.Store(tail)
+	return dst
+}
+
+func (q *ringQueue) len() int {
+	return int(q.head.Load() - q.tail.Load())
+}
+
+func (q *ringQueue) cap() int {
+	return len(q.slots)
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	runtimeStatsMu   sync.Mutex
+	runtimeStatsStop chan struct{}
+)
+
+// startRuntimeStats replaces the running reporter, interval <= 0 only stops it
+func startRuntimeStats(interval time.Duration, level zapcore.Level) {
+	runtimeStatsMu.Lock()
+	defer runtimeStatsMu.Unlock()
+
+	if runtimeStatsStop != nil {
+		close(runtimeStatsStop)
+		runtimeStatsStop = nil
+	}
+	if interval <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	runtimeStatsStop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var lastGC uint32
+		for {
+			select {
+			case <-ticker.C:
+				log := &Log{}
+				log.Log(level, "runtime stats", runtimeStatsFields(&lastGC)...)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// runtimeStatsFields reads the stats, lastGC tracks the GC cycles already reported
+func runtimeStatsFields(lastGC *uint32) []zapcore.Field {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	// PauseNs is a circular buffer of the last 256 pauses
+	var maxPause, totalPause uint64
+	cycles := m.NumGC - *lastGC
+	if cycles > uint32(len(m.PauseNs)) {
+		cycles = uint32(len(m.PauseNs))
+	}
+	for i := uint32(0); i < cycles; i++ {
+		pause := m.PauseNs[(m.NumGC-i+255)%256]
+		totalPause += pause
+		if pause > maxPause {
+			maxPause = pause
+		}
+	}
+	*lastGC = m.NumGC
+
+	fields := []zapcore.Field{
+		Int("goroutines", runtime.NumGoroutine()),
+		Int64("heap_alloc", int64(m.HeapAlloc)),
+		Int64("heap_inuse", int64(m.HeapInuse)),
+		Int64("heap_objects", int64(m.HeapObjects)),
+		Int64("sys", int64(m.Sys)),
+		Int64("gc_count", int64(m.NumGC)),
+		Int64("gc_cycles", int64(cycles)),
+		Duration("gc_pause_total", time.Duration(totalPause)),
+		Duration("gc_pause_max", time.Duration(maxPause)),
+	}
+	if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+		fields = append(fields, Int("open_fds", len(fds)))
+	}
+	return fields
+}
@@ -0,0 +1,176 @@
+package logger
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SamplingConfig drops repeated entries, per tick the First entries with a
+// given level and message are logged, then every Thereafter-th one
+type SamplingConfig struct {
+	// Tick is the sampling window, default 1s
+	Tick time.Duration
+	// First entries per level and message logged each tick, default 100
+	First int
+	// Thereafter logs every Nth entry after First, 0 drops them all
+	Thereafter int
+	// RateLimit caps the sampled entries of a tick across all messages, 0 is unlimited
+	RateLimit int
+	// Exempt lists more levels never sampled nor rate limited, e.g.
+	// {zapcore.WarnLevel: true}, error and above (including critical)
+	// always are
+	Exempt map[zapcore.Level]bool
+	// ReportInterval logs, at this interval, one "log entries sampled"
+	// entry per level and message with the count of the entries dropped
+	// and the window, at the level of the dropped entries, disabled when 0
+	ReportInterval time.Duration
+}
+
+type sampleKey struct {
+	level zapcore.Level
+	msg   string
+}
+
+type sampler struct {
+	config SamplingConfig
+	mu     sync.Mutex
+	tick   time.Time
+	counts map[sampleKey]int
+	total  int
+	// suppressed counts the entries dropped since the last report
+	suppressed map[sampleKey]uint64
+	since      time.Time
+	// out receives the reports, bypassing the sampler
+	out  zapcore.Core
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newSampler(config SamplingConfig) *sampler {
+	if config.Tick <= 0 {
+		config.Tick = time.Second
+	}
+	if config.First <= 0 {
+		config.First = 100
+	}
+	return &sampler{config: config, counts: make(map[sampleKey]int)}
+}
+
+// startReport logs the suppressed entries to out every report interval
+// until Close
+func (s *sampler) startReport(out zapcore.Core) {
+	s.out = out
+	s.suppressed = make(map[sampleKey]uint64)
+	s.since = time.Now()
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.config.ReportInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.report()
+			case <-s.stop:
+				s.report()
+				return
+			}
+		}
+	}()
+}
+
+// report logs and resets the suppressed counts
+func (s *sampler) report() {
+	s.mu.Lock()
+	suppressed, since := s.suppressed, s.since
+	now := time.Now()
+	s.since = now
+	if len(suppressed) > 0 {
+		s.suppressed = make(map[sampleKey]uint64)
+	}
+	s.mu.Unlock()
+
+	keys := make([]sampleKey, 0, len(suppressed))
+	for k := range suppressed {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].level != keys[j].level {
+			return levelRank(keys[i].level) < levelRank(keys[j].level)
+		}
+		return keys[i].msg < keys[j].msg
+	})
+	for _, k := range keys {
+		ent := zapcore.Entry{Level: k.level, Time: now, Message: "log entries sampled"}
+		writeEntry(s.out, ent, []zapcore.Field{
+			String("sampled_msg", k.msg),
+			String("sampled_level", levelName(k.level)),
+			Int64("suppressed", int64(suppressed[k])),
+			zap.Time("window_start", since),
+			zap.Time("window_end", now),
+			Duration("window", now.Sub(since)),
+		})
+	}
+}
+
+func (s *sampler) Close() error {
+	if s.stop == nil {
+		return nil
+	}
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	<-s.done
+	return nil
+}
+
+func (s *sampler) exempt(level zapcore.Level) bool {
+	return levelAtLeast(level, zapcore.ErrorLevel) || s.config.Exempt[level]
+}
+
+// allow counts the entry and reports whether it is kept
+func (s *sampler) allow(ent zapcore.Entry) bool {
+	if s.exempt(ent.Level) {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tick := ent.Time.Truncate(s.config.Tick); !tick.Equal(s.tick) {
+		s.tick = tick
+		s.total = 0
+		for k := range s.counts {
+			delete(s.counts, k)
+		}
+	}
+
+	key := sampleKey{level: ent.Level, msg: ent.Message}
+	n := s.counts[key] + 1
+	s.counts[key] = n
+
+	keep := n <= s.config.First ||
+		s.config.Thereafter > 0 && (n-s.config.First)%s.config.Thereafter == 0
+	if keep && s.config.RateLimit > 0 {
+		if s.total >= s.config.RateLimit {
+			keep = false
+		} else {
+			s.total++
+		}
+	}
+	if !keep && s.suppressed != nil {
+		s.suppressed[key]++
+	}
+	return keep
+}
+
+func (s *sampler) process(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+	return ent, fields, s.allow(ent)
+}
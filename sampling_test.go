@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// sampledLogger returns a logger sampling like config and the entries it
+// delivered
+func sampledLogger(t *testing.T, config SamplingConfig) (*zap.Logger, *observer.ObservedLogs) {
+	// one tick spans the whole test so the counts are deterministic
+	config.Tick = time.Hour
+	obs, logs := observer.New(zapcore.DebugLevel)
+	core, closers := wrapCore(obs, Config{Sampling: &config})
+	t.Cleanup(func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	})
+	return zap.New(core), logs
+}
+
+func TestSamplingKeepsErrorsInOrder(t *testing.T) {
+	log, logs := sampledLogger(t, SamplingConfig{First: 1000, Thereafter: 1, RateLimit: 20})
+
+	const workers, perWorker = 8, 2000
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				log.Info("flood", zap.Int("worker", w), zap.Int("seq", i))
+				if i%10 == 0 {
+					log.Error("failure", zap.Int("worker", w), zap.Int("seq", i))
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	next := make(map[int64]int64)
+	infos := 0
+	for _, e := range logs.All() {
+		if e.Level == zapcore.InfoLevel {
+			infos++
+			continue
+		}
+		fields := e.ContextMap()
+		w, seq := fields["worker"].(int64), fields["seq"].(int64)
+		if seq != next[w] {
+			t.Fatalf("worker %d: error %d delivered, want %d", w, seq, next[w])
+		}
+		next[w] = seq + 10
+	}
+	for w := int64(0); w < workers; w++ {
+		if next[w] != perWorker {
+			t.Errorf("worker %d: errors delivered up to %d, want %d", w, next[w], perWorker)
+		}
+	}
+	if infos != 20 {
+		t.Errorf("%d info entries delivered, want the rate limit of 20", infos)
+	}
+}
+
+func TestSamplingExemptLevels(t *testing.T) {
+	log, logs := sampledLogger(t, SamplingConfig{
+		First:  1,
+		Exempt: map[zapcore.Level]bool{zapcore.WarnLevel: true},
+	})
+
+	for i := 0; i < 100; i++ {
+		log.Info("info")
+		log.Warn("warn")
+		log.Error("error")
+		if ce := log.Check(CriticalLevel, "critical"); ce != nil {
+			ce.Write()
+		}
+	}
+
+	want := map[zapcore.Level]int{zapcore.InfoLevel: 1, zapcore.WarnLevel: 100, zapcore.ErrorLevel: 100, CriticalLevel: 100}
+	for level, n := range want {
+		if got := logs.FilterLevelExact(level).Len(); got != n {
+			t.Errorf("%s: %d entries delivered, want %d", level, got, n)
+		}
+	}
+}
+
+func TestSamplingDefaultExemptsCritical(t *testing.T) {
+	log, logs := sampledLogger(t, SamplingConfig{First: 1})
+
+	for i := 0; i < 50; i++ {
+		if ce := log.Check(CriticalLevel, "critical"); ce != nil {
+			ce.Write()
+		}
+	}
+	if got := logs.FilterMessage("critical").Len(); got != 50 {
+		t.Errorf("%d critical entries delivered, want 50", got)
+	}
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SchemaVersionKey is the field stamped by Config.SchemaVersion
+const SchemaVersionKey = "schema_version"
+
+// SchemaViolation describes an entry not matching the registered schema
+type SchemaViolation struct {
+	Message  string
+	Level    zapcore.Level
+	Problems []string
+}
+
+// entrySchema is the supported subset of JSON schema
+type entrySchema struct {
+	Required   []string `json:"required"`
+	Properties map[string]struct {
+		Type string `json:"type"`
+	} `json:"properties"`
+	AdditionalProperties *bool `json:"additionalProperties"`
+}
+
+var currentSchema atomic.Pointer[entrySchema]
+
+// RegisterSchema sets the JSON schema entries are validated against when
+// Config.SchemaValidation is enabled. The "required", "properties" with
+// their "type" and "additionalProperties" keywords are supported and apply
+// to the fields of the entry, e.g.
+//
+//	{
+//	  "required": ["service", "request_id"],
+//	  "properties": {"request_id": {"type": "string"}, "status": {"type": "integer"}}
+//	}
+func RegisterSchema(data []byte) error {
+	schema := &entrySchema{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return err
+	}
+	for key, prop := range schema.Properties {
+		switch prop.Type {
+		case "", "string", "integer", "number", "boolean", "object", "array", "null":
+		default:
+			return errors.New("Bad schema type " + prop.Type + " for " + key)
+		}
+	}
+	currentSchema.Store(schema)
+	return nil
+}
+
+// schemaType names a map encoder value with JSON schema wording
+func schemaType(v interface{}) (string, bool) {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr:
+		return "number", true
+	}
+	switch typ := jsonType(v); typ {
+	case "bool":
+		return "boolean", false
+	case "time":
+		return "string", false
+	default:
+		return typ, false
+	}
+}
+
+func (s *entrySchema) check(values map[string]interface{}) []string {
+	var problems []string
+	for _, key := range s.Required {
+		if _, ok := values[key]; !ok {
+			problems = append(problems, "missing "+key)
+		}
+	}
+	for key, value := range values {
+		prop, ok := s.Properties[key]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				problems = append(problems, "unexpected "+key)
+			}
+			continue
+		}
+		if prop.Type == "" {
+			continue
+		}
+		typ, integer := schemaType(value)
+		if typ == prop.Type || prop.Type == "integer" && integer {
+			continue
+		}
+		problems = append(problems, key+" is "+typ+" not "+prop.Type)
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+func newSchemaValidator(handler func(SchemaViolation)) processFunc {
+	return func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+		return validateSchema(ent, context, fields, handler)
+	}
+}
+
+func validateSchema(ent zapcore.Entry, context, fields []zapcore.Field, handler func(SchemaViolation)) (zapcore.Entry, []zapcore.Field, bool) {
+	schema := currentSchema.Load()
+	if schema == nil {
+		return ent, fields, true
+	}
+
+	values := fieldMap(context)
+	for k, v := range fieldMap(fields) {
+		values[k] = v
+	}
+	problems := schema.check(values)
+	if len(problems) == 0 {
+		return ent, fields, true
+	}
+
+	if handler != nil {
+		handler(SchemaViolation{Message: ent.Message, Level: ent.Level, Problems: problems})
+	}
+	return ent, append(fields, Strings("schema_violations", problems)), true
+}
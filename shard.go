@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// shardedFile spreads writes over several logfiles so concurrent writers
+// do not queue on a single lock, every entry goes to the first free shard.
+// Entries stay whole within a shard but ordering across shards is lost, the
+// collector merges them by timestamp.
+type shardedFile struct {
+	shards []fileShard
+	next   atomic.Uint32
+}
+
+type fileShard struct {
+	mu   sync.Mutex
+	file rollingFile
+}
+
+// shardPath inserts the shard number before the extension, app.log becomes app.2.log
+func shardPath(path string, shard int) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + strconv.Itoa(shard) + ext
+}
+
+func newShardedFile(config Config, path string) *shardedFile {
+	f := &shardedFile{shards: make([]fileShard, config.FileShards)}
+	for i := range f.shards {
+		f.shards[i].file = newRollingFileWriter(newLumberjack(config, shardPath(path, i)), config.FileAttrs)
+	}
+	return f
+}
+
+func (f *shardedFile) Write(p []byte) (int, error) {
+	n := uint32(len(f.shards))
+	start := f.next.Add(1)
+	for i := uint32(0); i < n; i++ {
+		s := &f.shards[(start+i)%n]
+		if s.mu.TryLock() {
+			defer s.mu.Unlock()
+			return s.file.Write(p)
+		}
+	}
+
+	// every shard is busy, wait for ours
+	s := &f.shards[start%n]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Write(p)
+}
+
+func (f *shardedFile) Sync() error {
+	var err error
+	for i := range f.shards {
+		if serr := f.shards[i].file.Sync(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+func (f *shardedFile) Close() error {
+	var err error
+	for i := range f.shards {
+		s := &f.shards[i]
+		s.mu.Lock()
+		if cerr := s.file.Close(); err == nil {
+			err = cerr
+		}
+		s.mu.Unlock()
+	}
+	return err
+}
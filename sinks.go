@@ -0,0 +1,314 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Sink types
+const (
+	// SinkConsole writes to stdout, or stderr with Stderr set
+	SinkConsole = "console"
+	// SinkFile writes to a rolling file, see File
+	SinkFile       = "file"
+	SinkSocket     = "socket"
+	SinkNATS       = "nats"
+	SinkClickHouse = "clickhouse"
+	SinkSQLite     = "sqlite"
+	SinkMQTT       = "mqtt"
+	SinkEventLog   = "eventlog"
+	// SinkSIEM ships CEF or LEEF entries, see CEF
+	SinkSIEM = "siem"
+	// SinkJournald writes to the systemd journal, Journald is optional
+	SinkJournald = "journald"
+	// SinkIncident pages PagerDuty or Opsgenie on panic and fatal entries,
+	// see Incident
+	SinkIncident = "incident"
+	// SinkEmail mails digests of the error and above entries, see Email
+	SinkEmail = "email"
+	// SinkAzureMonitor sends to a Log Analytics workspace, see AzureMonitor
+	SinkAzureMonitor = "azuremonitor"
+)
+
+// SinkConfig declares one output, Type selects the option struct read:
+//
+//	Sinks: []logger.SinkConfig{
+//		{Type: logger.SinkConsole, Stderr: true, Encoding: logger.EncodingDev},
+//		{Type: logger.SinkFile, Level: &warn, File: &logger.FileSinkConfig{Filename: "errors.log"}},
+//		{Type: logger.SinkSocket, Socket: &logger.SocketConfig{Network: "tcp", Address: "collector:5170"}},
+//	}
+//
+// The legacy per-sink Config fields (Socket, NATS, ...) are still
+// honoured, they behave like a SinkConfig of their type.
+type SinkConfig struct {
+	// Type of the sink, one of the Sink constants
+	Type string
+	// Name identifies the sink in the internal logger, default Type
+	Name string
+	// Encoding of the entries, default the Config encoding for console and
+	// file sinks and EncodingJSON for the others
+	Encoding string
+	// Level is the lowest level of the sink, entries below the logger level
+	// never reach any sink
+	Level *zapcore.Level
+	// Stderr makes a console sink write to stderr
+	Stderr bool
+
+	File         *FileSinkConfig
+	Socket       *SocketConfig
+	NATS         *NATSConfig
+	ClickHouse   *ClickHouseConfig
+	SQLite       *SQLiteConfig
+	MQTT         *MQTTConfig
+	EventLog     *EventLogConfig
+	CEF          *CEFConfig
+	Journald     *JournaldConfig
+	Incident     *IncidentConfig
+	Email        *EmailConfig
+	AzureMonitor *AzureMonitorConfig
+}
+
+// FileSinkConfig configures a file sink, rolled like the logfile
+type FileSinkConfig struct {
+	// Directory of the file, default the Config directory or "."
+	Directory string
+	Filename  string
+	// MaxSize, MaxBackups and MaxAge default to the Config values
+	MaxSize    int
+	MaxBackups int
+	MaxAge     int
+}
+
+func (s SinkConfig) name() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Type
+}
+
+// target describes where the sink writes to
+func (s SinkConfig) target() string {
+	switch {
+	case s.Type == SinkConsole && s.Stderr:
+		return "stderr"
+	case s.Type == SinkConsole:
+		return "stdout"
+	case s.File != nil:
+		return s.File.Filename
+	case s.Socket != nil:
+		return s.Socket.Address
+	case s.NATS != nil:
+		return s.NATS.URL
+	case s.ClickHouse != nil:
+		return s.ClickHouse.Table
+	case s.SQLite != nil:
+		return s.SQLite.Path
+	case s.MQTT != nil:
+		return s.MQTT.Broker
+	case s.EventLog != nil:
+		return s.EventLog.Source
+	case s.CEF != nil && s.CEF.Socket != nil:
+		return s.CEF.Socket.Address
+	case s.Type == SinkJournald:
+		if s.Journald != nil && s.Journald.Socket != "" {
+			return s.Journald.Socket
+		}
+		return defaultJournalSocket
+	case s.Incident != nil:
+		return s.Incident.Provider
+	case s.Email != nil:
+		return s.Email.Addr
+	case s.AzureMonitor != nil:
+		return s.AzureMonitor.WorkspaceID + "/" + s.AzureMonitor.LogType
+	}
+	return ""
+}
+
+// encoding resolves the encoding of the sink
+func (s SinkConfig) encoding(config Config) string {
+	if s.Encoding != "" {
+		return s.Encoding
+	}
+	switch s.Type {
+	case SinkConsole:
+		return config.consoleEncoding()
+	case SinkFile:
+		return config.encoding()
+	case SinkSIEM:
+		if s.CEF != nil && s.CEF.Format == EncodingLEEF {
+			return EncodingLEEF
+		}
+		return EncodingCEF
+	}
+	return EncodingJSON
+}
+
+// sinks lists the sinks declared by the legacy fields, then Sinks
+func (c Config) sinks() []SinkConfig {
+	var sinks []SinkConfig
+	if c.Socket != nil {
+		sinks = append(sinks, SinkConfig{Type: SinkSocket, Socket: c.Socket})
+	}
+	if c.NATS != nil {
+		sinks = append(sinks, SinkConfig{Type: SinkNATS, NATS: c.NATS})
+	}
+	if c.ClickHouse != nil {
+		sinks = append(sinks, SinkConfig{Type: SinkClickHouse, ClickHouse: c.ClickHouse})
+	}
+	if c.SQLite != nil {
+		sinks = append(sinks, SinkConfig{Type: SinkSQLite, SQLite: c.SQLite})
+	}
+	if c.MQTT != nil {
+		sinks = append(sinks, SinkConfig{Type: SinkMQTT, MQTT: c.MQTT})
+	}
+	if c.EventLog != nil {
+		sinks = append(sinks, SinkConfig{Type: SinkEventLog, EventLog: c.EventLog})
+	}
+	if c.CEF != nil && c.CEF.Socket != nil {
+		sinks = append(sinks, SinkConfig{Type: SinkSIEM, CEF: c.CEF})
+	}
+	return append(sinks, c.Sinks...)
+}
+
+// sinkLevelEnabler applies the sink level on top of the logger levels
+func sinkLevelEnabler(min *zapcore.Level) zapcore.LevelEnabler {
+	if min == nil {
+		return newSinkLevelEnabler()
+	}
+	level := *min
+	return zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return levelAtLeast(l, level) && levelAtLeast(l, lowestLevel())
+	})
+}
+
+var errSinkOptions = errors.New("Bad sink type or missing sink options")
+
+// newSinkCore opens the sink, the closer releases it when not nil
+func newSinkCore(config Config, sink SinkConfig) (zapcore.Core, io.Closer, error) {
+	enc := newOutputEncoder(config, sink.encoding(config), nil)
+	enab := sinkLevelEnabler(sink.Level)
+
+	var out entryWriter
+	var closer io.Closer
+	switch {
+	case sink.Type == SinkConsole:
+		w := os.Stdout
+		if sink.Stderr {
+			w = os.Stderr
+		}
+		return zapcore.NewCore(newOutputEncoder(config, sink.encoding(config), w), zapcore.Lock(w), enab), nil, nil
+	case sink.Type == SinkFile && sink.File != nil:
+		fc := config
+		fc.Mmap, fc.FileShards, fc.Retention = nil, 0, nil
+		if sink.File.Directory != "" {
+			fc.Directory = sink.File.Directory
+		}
+		fc.Filename = sink.File.Filename
+		if sink.File.MaxSize != 0 {
+			fc.MaxSize = sink.File.MaxSize
+		}
+		if sink.File.MaxBackups != 0 {
+			fc.MaxBackups = sink.File.MaxBackups
+		}
+		if sink.File.MaxAge != 0 {
+			fc.MaxAge = sink.File.MaxAge
+		}
+		if fc.Filename == "" {
+			return nil, nil, errors.New("Bad file sink filename")
+		}
+		file := newRollingFile(fc)
+		if file == nil {
+			return nil, nil, errors.New("Bad file sink directory")
+		}
+		return zapcore.NewCore(enc, file, enab), file.(io.Closer), nil
+	case sink.Type == SinkSocket && sink.Socket != nil:
+		w, err := newSocketWriter(*sink.Socket)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zapcore.NewCore(enc, w, enab), w, nil
+	case sink.Type == SinkNATS && sink.NATS != nil:
+		w, err := newNATSWriter(*sink.NATS)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closer = w, w
+	case sink.Type == SinkClickHouse && sink.ClickHouse != nil:
+		w, err := newClickHouseWriter(*sink.ClickHouse)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closer = w, w
+	case sink.Type == SinkSQLite && sink.SQLite != nil:
+		w, err := newSQLiteWriter(*sink.SQLite)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closer = w, w
+	case sink.Type == SinkMQTT && sink.MQTT != nil:
+		w, err := newMQTTWriter(*sink.MQTT)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closer = w, w
+	case sink.Type == SinkEventLog && sink.EventLog != nil:
+		w, err := newEventLogWriter(*sink.EventLog)
+		if err != nil {
+			return nil, nil, err
+		}
+		if sink.Level == nil {
+			level := sink.EventLog.minLevel()
+			enab = sinkLevelEnabler(&level)
+		}
+		out, closer = w, w
+	case sink.Type == SinkSIEM && sink.CEF != nil && sink.CEF.Socket != nil:
+		w, err := newCEFWriter(*sink.CEF)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closer = w, w
+	case sink.Type == SinkJournald:
+		config := JournaldConfig{}
+		if sink.Journald != nil {
+			config = *sink.Journald
+		}
+		w, err := newJournaldWriter(config)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closer = w, w
+	case sink.Type == SinkIncident && sink.Incident != nil:
+		w, err := newIncidentWriter(*sink.Incident)
+		if err != nil {
+			return nil, nil, err
+		}
+		if sink.Level == nil {
+			level := zapcore.PanicLevel
+			enab = sinkLevelEnabler(&level)
+		}
+		out, closer = w, w
+	case sink.Type == SinkAzureMonitor && sink.AzureMonitor != nil:
+		w, err := newAzureWriter(*sink.AzureMonitor)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closer = w, w
+	case sink.Type == SinkEmail && sink.Email != nil:
+		w, err := newEmailWriter(*sink.Email)
+		if err != nil {
+			return nil, nil, err
+		}
+		if sink.Level == nil {
+			level := zapcore.ErrorLevel
+			enab = sinkLevelEnabler(&level)
+		}
+		out, closer = w, w
+	default:
+		return nil, nil, errSinkOptions
+	}
+	return newEntryCore(enc, out, enab), closer, nil
+}
@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap/zapcore"
+)
+
+// SLOField is the default key naming the SLO of an entry, see SLO
+const SLOField = "slo"
+
+// SLOTracker receives the entries counting against an SLO, Event must not
+// block, it is called on the goroutine logging
+type SLOTracker interface {
+	// Event records one event of slo, failed when it was logged at the
+	// failure level or above
+	Event(slo string, failed bool)
+}
+
+// SLOConfig feeds the entries to an SLO tracker: the entries carrying the
+// SLO field are events of that SLO, failed at Level and above, and the
+// entries at Level and above without the field are failed events of the
+// SLO named after their logger. A request log then drives the burn rate:
+//
+//	log.Info("Request served", logger.SLO("checkout"))
+//	log.Error("Payment declined", logger.SLO("checkout"), logger.Err(err))
+type SLOConfig struct {
+	Tracker SLOTracker
+	// Field naming the SLO, default "slo"
+	Field string
+	// Level of the failed events, default error
+	Level *zapcore.Level
+}
+
+// SLO is the field naming the SLO an entry counts against
+func SLO(name string) zapcore.Field {
+	return String(SLOField, name)
+}
+
+func newSLOProcess(config SLOConfig) processFunc {
+	if config.Field == "" {
+		config.Field = SLOField
+	}
+	failLevel := zapcore.ErrorLevel
+	if config.Level != nil {
+		failLevel = *config.Level
+	}
+	return func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+		failed := levelAtLeast(ent.Level, failLevel)
+		if slo, ok := fieldValue(config.Field, fields, context); ok {
+			config.Tracker.Event(slo, failed)
+		} else if failed {
+			config.Tracker.Event(ent.LoggerName, true)
+		}
+		return ent, fields, true
+	}
+}
+
+// PrometheusSLOTracker is the reference SLOTracker, it counts the events
+// in log_slo_events_total and the failed ones in log_slo_failures_total,
+// both labelled by slo, and exports the objectives set with SetObjective as
+// log_slo_objective. The burn rate over an hour is then
+//
+//	(rate(log_slo_failures_total[1h]) / rate(log_slo_events_total[1h]))
+//		/ on(slo) (1 - log_slo_objective)
+//
+// alerting above 14.4 pages when 2% of a 30 days budget burns in an hour.
+type PrometheusSLOTracker struct {
+	events     *prometheus.CounterVec
+	failures   *prometheus.CounterVec
+	objectives *prometheus.GaugeVec
+}
+
+// NewPrometheusSLOTracker registers the SLO metrics with reg
+func NewPrometheusSLOTracker(reg prometheus.Registerer, namespace string) (*PrometheusSLOTracker, error) {
+	t := &PrometheusSLOTracker{
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "log_slo_events_total",
+			Help:      "Log entries counting against an SLO",
+		}, []string{"slo"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "log_slo_failures_total",
+			Help:      "Log entries failing an SLO",
+		}, []string{"slo"}),
+		objectives: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "log_slo_objective",
+			Help:      "Target ratio of good events of an SLO",
+		}, []string{"slo"}),
+	}
+	for _, c := range []prometheus.Collector{t.events, t.failures, t.objectives} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// SetObjective sets the target ratio of good events of slo, e.g. 0.999
+func (t *PrometheusSLOTracker) SetObjective(slo string, objective float64) {
+	t.objectives.WithLabelValues(slo).Set(objective)
+}
+
+// Event implements SLOTracker
+func (t *PrometheusSLOTracker) Event(slo string, failed bool) {
+	t.events.WithLabelValues(slo).Inc()
+	if failed {
+		t.failures.WithLabelValues(slo).Inc()
+	}
+}
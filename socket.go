@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Framing modes for the socket sink
+const (
+	// FramingNewline terminates every entry with '\n' (newline delimited JSON)
+	FramingNewline = "newline"
+	// FramingLength prefixes every entry with its length as a 4 byte big endian integer
+	FramingLength = "length"
+)
+
+// SocketConfig configures a raw socket sink, entries are always encoded as JSON
+type SocketConfig struct {
+	// Network is one of "tcp", "udp" or "unix"
+	Network string
+	// Address is host:port for tcp/udp or a socket path for unix
+	Address string
+	// Framing is FramingNewline (default) or FramingLength
+	Framing string
+	// DialTimeout bounds a single connection attempt, default 5s
+	DialTimeout time.Duration
+	// KeepAlive is the tcp keepalive period, 0 uses the system default
+	KeepAlive time.Duration
+	// MinBackoff is the first reconnect delay after a failure, default 100ms
+	MinBackoff time.Duration
+	// MaxBackoff caps the reconnect delay, default 30s
+	MaxBackoff time.Duration
+	// TLS enables TLS on stream connections when not nil
+	TLS *TLSConfig
+}
+
+var errSocketConfig = errors.New("Bad socket network or address")
+
+type socketWriter struct {
+	mu       sync.Mutex
+	config   SocketConfig
+	tls      *tls.Config
+	conn     net.Conn
+	backoff  time.Duration
+	nextDial time.Time
+}
+
+func newSocketWriter(config SocketConfig) (*socketWriter, error) {
+	switch config.Network {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "unix", "unixgram":
+	default:
+		return nil, errSocketConfig
+	}
+	if config.Address == "" {
+		return nil, errSocketConfig
+	}
+	if config.Framing == "" {
+		config.Framing = FramingNewline
+	}
+	if config.Framing != FramingNewline && config.Framing != FramingLength {
+		return nil, errors.New("Bad socket framing")
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = 5 * time.Second
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = 100 * time.Millisecond
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = 30 * time.Second
+	}
+
+	w := &socketWriter{config: config}
+	if config.TLS != nil {
+		tlsConfig, err := config.TLS.Build()
+		if err != nil {
+			return nil, err
+		}
+		w.tls = tlsConfig
+	}
+
+	return w, nil
+}
+
+func (w *socketWriter) stream() bool {
+	switch w.config.Network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return false
+	}
+	return true
+}
+
+func (w *socketWriter) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: w.config.DialTimeout, KeepAlive: w.config.KeepAlive}
+	if w.tls != nil && w.stream() {
+		return tls.DialWithDialer(dialer, w.config.Network, w.config.Address, w.tls)
+	}
+	return dialer.Dial(w.config.Network, w.config.Address)
+}
+
+// connect dials unless a previous failure put the writer into backoff
+func (w *socketWriter) connect() (bool, error) {
+	if time.Now().Before(w.nextDial) {
+		return false, nil
+	}
+	conn, err := w.dial()
+	if err != nil {
+		w.fail()
+		internalLog(zapcore.WarnLevel, "Sink connect failed", String("sink", "socket"), String("address", w.config.Address), Err(err), Duration("retry_in", w.backoff))
+		return false, err
+	}
+	if w.backoff != 0 {
+		internalLog(zapcore.InfoLevel, "Sink reconnected", String("sink", "socket"), String("address", w.config.Address))
+	}
+	w.conn = conn
+	w.backoff = 0
+	return true, nil
+}
+
+func (w *socketWriter) fail() {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+	if w.backoff == 0 {
+		w.backoff = w.config.MinBackoff
+	} else {
+		w.backoff *= 2
+	}
+	if w.backoff > w.config.MaxBackoff {
+		w.backoff = w.config.MaxBackoff
+	}
+	w.nextDial = time.Now().Add(w.backoff)
+}
+
+func (w *socketWriter) frame(p []byte) []byte {
+	if w.config.Framing == FramingLength {
+		n := len(p)
+		if n > 0 && p[n-1] == '\n' {
+			n--
+		}
+		frame := make([]byte, 4+n)
+		binary.BigEndian.PutUint32(frame, uint32(n))
+		copy(frame[4:], p[:n])
+		return frame
+	}
+	if len(p) > 0 && p[len(p)-1] == '\n' {
+		return p
+	}
+	return append(append(make([]byte, 0, len(p)+1), p...), '\n')
+}
+
+// Write sends one encoded entry, entries written while reconnecting are dropped
+func (w *socketWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		ok, err := w.connect()
+		if !ok {
+			return len(p), err
+		}
+	}
+	if _, err := w.conn.Write(w.frame(p)); err != nil {
+		w.fail()
+		internalLog(zapcore.WarnLevel, "Sink connection lost", String("sink", "socket"), String("address", w.config.Address), Err(err))
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (w *socketWriter) Sync() error {
+	return nil
+}
+
+func (w *socketWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SourceURLField is the key of the link added by Config.SourceLink
+const SourceURLField = "source_url"
+
+// SourceLinkConfig adds a "source_url" field linking the call site of the
+// error and above entries to the repository, e.g.
+//
+//	SourceLink: &logger.SourceLinkConfig{
+//		Template:   "https://github.com/acme/svc/blob/{commit}/{path}#L{line}",
+//		ModuleRoot: "/src/svc/",
+//	}
+//
+// Call sites outside ModuleRoot, in dependencies, get no link.
+type SourceLinkConfig struct {
+	// Template is the link, {commit}, {path} (relative to ModuleRoot) and
+	// {line} are replaced
+	Template string
+	// ModuleRoot is the prefix of the source file paths stripped to get
+	// the repository paths, the checkout directory of the build or, for
+	// -trimpath builds, the module path. Default the main module path.
+	ModuleRoot string
+	// Commit is the revision linked to, default the vcs.revision of
+	// BuildInfoFields
+	Commit string
+}
+
+func (c SourceLinkConfig) withDefaults() SourceLinkConfig {
+	if c.ModuleRoot == "" {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			c.ModuleRoot = info.Main.Path
+		}
+	}
+	if c.ModuleRoot != "" && !strings.HasSuffix(c.ModuleRoot, "/") {
+		c.ModuleRoot += "/"
+	}
+	if c.Commit == "" {
+		for _, f := range BuildInfoFields() {
+			if f.Key == "vcs.revision" {
+				c.Commit = f.String
+			}
+		}
+	}
+	return c
+}
+
+func newSourceLinkProcess(config SourceLinkConfig) processFunc {
+	config = config.withDefaults()
+	return func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+		if !levelAtLeast(ent.Level, zapcore.ErrorLevel) || !ent.Caller.Defined {
+			return ent, fields, true
+		}
+		if config.ModuleRoot == "" || !strings.HasPrefix(ent.Caller.File, config.ModuleRoot) {
+			return ent, fields, true
+		}
+		url := strings.NewReplacer(
+			"{commit}", config.Commit,
+			"{path}", strings.TrimPrefix(ent.Caller.File, config.ModuleRoot),
+			"{line}", strconv.Itoa(ent.Caller.Line),
+		).Replace(config.Template)
+		return ent, append(fields, String(SourceURLField, url)), true
+	}
+}
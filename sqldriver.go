@@ -0,0 +1,327 @@
+package logger
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DriverLogConfig configures WrapDriver
+type DriverLogConfig struct {
+	// Log receives the entries, default Named("sql")
+	Log *Log
+	// Level of the queries, default debug
+	Level zapcore.Level
+	// SlowThreshold logs the queries taking longer at the warn level,
+	// disabled when 0
+	SlowThreshold time.Duration
+	// Args logs the query arguments
+	Args bool
+	// Redact returns the logged value of an argument, e.g. to hide the
+	// password of an insert, by default []byte are logged as their length
+	Redact func(query string, arg driver.NamedValue) interface{}
+}
+
+// WrapDriver returns a driver logging the statements run through d, with
+// their arguments, duration and error, failures at the error level:
+//
+//	sql.Register("postgres-logged", logger.WrapDriver(&pq.Driver{}, logger.DriverLogConfig{
+//		SlowThreshold: 200 * time.Millisecond,
+//	}))
+//	db, err := sql.Open("postgres-logged", dsn)
+//
+// The entries are logged with Log.Ctx when the context variants are used.
+func WrapDriver(d driver.Driver, config DriverLogConfig) driver.Driver {
+	if config.Log == nil {
+		config.Log = Named("sql")
+	}
+	return &logDriver{Driver: d, config: config}
+}
+
+type logDriver struct {
+	driver.Driver
+	config DriverLogConfig
+}
+
+func (d *logDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		d.config.log(context.Background(), "open", "", nil, time.Now(), err)
+		return nil, err
+	}
+	return &logConn{Conn: conn, config: &d.config}, nil
+}
+
+// OpenConnector keeps the connector of drivers implementing
+// driver.DriverContext
+func (d *logDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.Driver.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &logConnector{Connector: c, driver: d}, nil
+	}
+	return &logConnector{name: name, driver: d}, nil
+}
+
+type logConnector struct {
+	driver.Connector
+	name   string
+	driver *logDriver
+}
+
+func (c *logConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if c.Connector == nil {
+		return c.driver.Open(c.name)
+	}
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		c.driver.config.log(ctx, "open", "", nil, time.Now(), err)
+		return nil, err
+	}
+	return &logConn{Conn: conn, config: &c.driver.config}, nil
+}
+
+func (c *logConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// log writes one statement, driver.ErrSkip only asks database/sql to
+// take another path and is not logged
+func (c *DriverLogConfig) log(ctx context.Context, op, query string, args []driver.NamedValue, start time.Time, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+	elapsed := time.Since(start)
+	level, msg := c.Level, "SQL "+op
+	switch {
+	case err != nil:
+		level, msg = zapcore.ErrorLevel, "SQL "+op+" failed"
+	case c.SlowThreshold > 0 && elapsed >= c.SlowThreshold:
+		level, msg = zapcore.WarnLevel, "Slow SQL "+op
+	}
+	// the statements are mostly logged at the debug level, build nothing
+	// for a disabled one
+	log := c.Log.Ctx(ctx)
+	ce := log.zap().Check(level, msg)
+	if ce == nil {
+		return
+	}
+
+	fields := []zapcore.Field{Duration("elapsed", elapsed)}
+	if query != "" {
+		fields = append(fields, String("query", query))
+	}
+	if c.Args && len(args) > 0 {
+		values := make([]interface{}, len(args))
+		for i, arg := range args {
+			values[i] = c.argValue(query, arg)
+		}
+		fields = append(fields, zap.Any("args", values))
+	}
+	if err != nil {
+		fields = append(fields, Err(err))
+	}
+	log.write(ce, fields)
+}
+
+func (c *DriverLogConfig) argValue(query string, arg driver.NamedValue) interface{} {
+	if c.Redact != nil {
+		return c.Redact(query, arg)
+	}
+	if b, ok := arg.Value.([]byte); ok {
+		return "<" + strconv.Itoa(len(b)) + " bytes>"
+	}
+	return arg.Value
+}
+
+// namedValues converts the arguments of the legacy interfaces
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+type logConn struct {
+	driver.Conn
+	config *DriverLogConfig
+}
+
+func (c *logConn) Prepare(query string) (driver.Stmt, error) {
+	start := time.Now()
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		c.config.log(context.Background(), "prepare", query, nil, start, err)
+		return nil, err
+	}
+	return &logStmt{Stmt: stmt, query: query, config: c.config}, nil
+}
+
+func (c *logConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	pc, ok := c.Conn.(driver.ConnPrepareContext)
+	if !ok {
+		return c.Prepare(query)
+	}
+	start := time.Now()
+	stmt, err := pc.PrepareContext(ctx, query)
+	if err != nil {
+		c.config.log(ctx, "prepare", query, nil, start, err)
+		return nil, err
+	}
+	return &logStmt{Stmt: stmt, query: query, config: c.config}, nil
+}
+
+func (c *logConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := ec.ExecContext(ctx, query, args)
+	c.config.log(ctx, "exec", query, args, start, err)
+	return res, err
+}
+
+func (c *logConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := qc.QueryContext(ctx, query, args)
+	c.config.log(ctx, "query", query, args, start, err)
+	return rows, err
+}
+
+func (c *logConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *logConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	start := time.Now()
+	var tx driver.Tx
+	var err error
+	if bc, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = bc.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	c.config.log(ctx, "begin", "", nil, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &logTx{Tx: tx, ctx: ctx, config: c.config}, nil
+}
+
+func (c *logConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *logConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *logConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *logConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type logTx struct {
+	driver.Tx
+	ctx    context.Context
+	config *DriverLogConfig
+}
+
+func (t *logTx) Commit() error {
+	start := time.Now()
+	err := t.Tx.Commit()
+	t.config.log(t.ctx, "commit", "", nil, start, err)
+	return err
+}
+
+func (t *logTx) Rollback() error {
+	start := time.Now()
+	err := t.Tx.Rollback()
+	t.config.log(t.ctx, "rollback", "", nil, start, err)
+	return err
+}
+
+type logStmt struct {
+	driver.Stmt
+	query  string
+	config *DriverLogConfig
+}
+
+func (s *logStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := time.Now()
+	res, err := s.Stmt.Exec(args)
+	s.config.log(context.Background(), "exec", s.query, namedValues(args), start, err)
+	return res, err
+}
+
+func (s *logStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := s.Stmt.Query(args)
+	s.config.log(context.Background(), "query", s.query, namedValues(args), start, err)
+	return rows, err
+}
+
+func (s *logStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		values := make([]driver.Value, len(args))
+		for i, arg := range args {
+			values[i] = arg.Value
+		}
+		return s.Exec(values)
+	}
+	start := time.Now()
+	res, err := ec.ExecContext(ctx, args)
+	s.config.log(ctx, "exec", s.query, args, start, err)
+	return res, err
+}
+
+func (s *logStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		values := make([]driver.Value, len(args))
+		for i, arg := range args {
+			values[i] = arg.Value
+		}
+		return s.Query(values)
+	}
+	start := time.Now()
+	rows, err := qc.QueryContext(ctx, args)
+	s.config.log(ctx, "query", s.query, args, start, err)
+	return rows, err
+}
+
+func (s *logStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
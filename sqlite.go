@@ -0,0 +1,181 @@
+package logger
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SQLiteConfig configures the local SQLite sink
+//
+// The driver is not linked by this package, import one in the application,
+// e.g. github.com/mattn/go-sqlite3 ("sqlite3") or modernc.org/sqlite ("sqlite")
+type SQLiteConfig struct {
+	// Path of the database file
+	Path string
+	// Driver is the database/sql driver name, default "sqlite3"
+	Driver string
+	// Table to write into, created when missing, default "logs"
+	Table string
+	// MaxRows keeps at most this many newest rows, 0 means unlimited
+	MaxRows int
+	// MaxAge deletes rows older than this, 0 means unlimited
+	MaxAge time.Duration
+	// BatchSize is the number of rows per transaction, default 100
+	BatchSize int
+	// FlushInterval is the max time rows wait before a commit, default 1s
+	FlushInterval time.Duration
+}
+
+type sqliteEntry struct {
+	ent    zapcore.Entry
+	fields string
+}
+
+type sqliteWriter struct {
+	config   SQLiteConfig
+	db       *sql.DB
+	batch    *batcher[sqliteEntry]
+	mu       sync.Mutex
+	inserted int
+	pruned   time.Time
+}
+
+func newSQLiteWriter(config SQLiteConfig) (*sqliteWriter, error) {
+	if config.Path == "" {
+		return nil, errors.New("Bad sqlite path")
+	}
+	if config.Driver == "" {
+		config.Driver = "sqlite3"
+	}
+	if config.Table == "" {
+		config.Table = "logs"
+	}
+	if config.MaxRows < 0 || config.MaxAge < 0 {
+		return nil, errors.New("Bad sqlite retention")
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+
+	db, err := sql.Open(config.Driver, config.Path)
+	if err != nil {
+		return nil, err
+	}
+	// sqlite allows a single writer, serialize in the pool instead of on SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	stmts := []string{
+		"PRAGMA journal_mode=WAL",
+		"PRAGMA synchronous=NORMAL",
+		"CREATE TABLE IF NOT EXISTS " + config.Table + " (" +
+			"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+			"ts INTEGER NOT NULL, " +
+			"level TEXT NOT NULL, " +
+			"logger TEXT, " +
+			"caller TEXT, " +
+			"message TEXT, " +
+			"fields TEXT)",
+		"CREATE INDEX IF NOT EXISTS " + config.Table + "_ts ON " + config.Table + " (ts)",
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	w := &sqliteWriter{config: config, db: db}
+	w.batch = newBatcher("sqlite", config.BatchSize, config.FlushInterval, w.insert)
+
+	return w, nil
+}
+
+func (w *sqliteWriter) WriteEntry(ent zapcore.Entry, fields []zapcore.Field, encoded []byte) error {
+	data, err := json.Marshal(fieldMap(fields))
+	if err != nil {
+		return err
+	}
+	return w.batch.Add(sqliteEntry{ent: ent, fields: string(data)})
+}
+
+func (w *sqliteWriter) insert(entries []sqliteEntry) error {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("INSERT INTO " + w.config.Table +
+		" (ts, level, logger, caller, message, fields) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range entries {
+		caller := ""
+		if e.ent.Caller.Defined {
+			caller = e.ent.Caller.String()
+		}
+		_, err := stmt.Exec(e.ent.Time.UnixMilli(), levelName(e.ent.Level), e.ent.LoggerName, caller, e.ent.Message, e.fields)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return w.prune(len(entries))
+}
+
+// prune applies retention at most once a minute or every BatchSize*10 rows
+func (w *sqliteWriter) prune(inserted int) error {
+	if w.config.MaxRows == 0 && w.config.MaxAge == 0 {
+		return nil
+	}
+
+	w.mu.Lock()
+	w.inserted += inserted
+	due := w.inserted >= w.config.BatchSize*10 || time.Since(w.pruned) > time.Minute
+	if due {
+		w.inserted = 0
+		w.pruned = time.Now()
+	}
+	w.mu.Unlock()
+	if !due {
+		return nil
+	}
+
+	if w.config.MaxAge > 0 {
+		cutoff := time.Now().Add(-w.config.MaxAge).UnixMilli()
+		if _, err := w.db.Exec("DELETE FROM "+w.config.Table+" WHERE ts < ?", cutoff); err != nil {
+			return err
+		}
+	}
+	if w.config.MaxRows > 0 {
+		_, err := w.db.Exec("DELETE FROM "+w.config.Table+" WHERE id <= "+
+			"(SELECT id FROM "+w.config.Table+" ORDER BY id DESC LIMIT 1 OFFSET ?)", w.config.MaxRows)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *sqliteWriter) Sync() error {
+	return w.batch.Flush()
+}
+
+func (w *sqliteWriter) Close() error {
+	err := w.batch.Close()
+	if cerr := w.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
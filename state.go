@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// retireDelay is how long a replaced configuration keeps its writers open
+// for the entries still being written through it
+const retireDelay = time.Second
+
+// loggerState is what Configure builds, it is swapped as a whole so
+// goroutines logging during a Configure see either the old or the new one
+type loggerState struct {
+	zap    *zap.Logger
+	config Config
+	// closers release the writers of this state, in order
+	closers []io.Closer
+}
+
+var (
+	// configureMu serializes Configure and SetLogLevel
+	configureMu sync.Mutex
+	state       atomic.Pointer[loggerState]
+	// rootLevel is the level of loggers without a named level
+	rootLevel atomic.Int32
+)
+
+func init() {
+	state.Store(&loggerState{zap: DefaultZapLogger})
+}
+
+// currentZap is the zap logger of the current configuration, use it rather
+// than DefaultZapLogger which is not safe to read while Configure runs
+func currentZap() *zap.Logger {
+	return state.Load().zap
+}
+
+// currentConfig is the current configuration, it must not be modified
+func currentConfig() *Config {
+	return &state.Load().config
+}
+
+func currentLevel() zapcore.Level {
+	return zapcore.Level(rootLevel.Load())
+}
+
+// swapState installs s and closes the writers of the replaced state once
+// the entries in flight had time to drain
+func swapState(s *loggerState) {
+	old := state.Swap(s)
+	if old == nil || len(old.closers) == 0 {
+		return
+	}
+	go func() {
+		time.Sleep(retireDelay)
+		for _, c := range old.closers {
+			if err := c.Close(); err != nil {
+				internalLog(zapcore.WarnLevel, "Failed close replaced writer", Err(err))
+			}
+		}
+	}()
+}
+
+// shutdowner is implemented by the writers doing more on a process exit
+// than on a reconfiguration, e.g. resolving incidents
+type shutdowner interface {
+	Shutdown() error
+}
+
+// Shutdown flushes and closes the writers of the current configuration
+// before the process exits, later entries go to stderr. It returns the
+// last error met.
+//
+//	defer logger.Shutdown()
+func Shutdown() error {
+	configureMu.Lock()
+	defer configureMu.Unlock()
+
+	stderr := zap.New(zapcore.NewCore(newEncoder(Config{}, EncodingConsole), zapcore.Lock(os.Stderr), zapcore.InfoLevel))
+	old := state.Swap(&loggerState{zap: stderr, config: *currentConfig()})
+	var failed error
+	if err := old.zap.Sync(); err != nil {
+		failed = err
+	}
+	for _, c := range old.closers {
+		if s, ok := c.(shutdowner); ok {
+			if err := s.Shutdown(); err != nil {
+				failed = err
+			}
+		}
+		if err := c.Close(); err != nil {
+			failed = err
+		}
+	}
+	return failed
+}
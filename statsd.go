@@ -0,0 +1,220 @@
+package logger
+
+import (
+	"bytes"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// StatsDConfig sends metrics derived from the entries to a StatsD server,
+// an alternative to Config.MetricsRegisterer: the count of entries per
+// level as <Prefix>log.entries, the Counter and Gauge fields, and the
+// Timers fields as timings. Metrics are aggregated and sent every Interval.
+type StatsDConfig struct {
+	// Address of the server, host:port over UDP
+	Address string
+	// Prefix of the metric names, e.g. "billing."
+	Prefix string
+	// DogStatsD sends the level and TagFields as tags, plain StatsD puts
+	// the level in the name, log.entries.error, and has no tags
+	DogStatsD bool
+	// Tags are sent with every metric, e.g. "env:prod", DogStatsD only
+	Tags []string
+	// TagFields are the keys of the fields whose values tag the entry
+	// counts, e.g. "status", DogStatsD only. Keep their cardinality low.
+	TagFields []string
+	// Timers are the keys of the duration or number (milliseconds) fields
+	// sent as timings
+	Timers []string
+	// Interval between two sends, default 10s
+	Interval time.Duration
+}
+
+type statsdKey struct {
+	name string
+	tags string
+}
+
+type statsdEmitter struct {
+	config StatsDConfig
+	conn   net.Conn
+	timers map[string]bool
+
+	mu       sync.Mutex
+	counters map[statsdKey]float64
+	gauges   map[statsdKey]float64
+	timings  map[statsdKey][]float64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newStatsDEmitter(config StatsDConfig) (*statsdEmitter, error) {
+	if config.Interval <= 0 {
+		config.Interval = 10 * time.Second
+	}
+	conn, err := net.Dial("udp", config.Address)
+	if err != nil {
+		return nil, err
+	}
+	s := &statsdEmitter{
+		config:   config,
+		conn:     conn,
+		timers:   make(map[string]bool, len(config.Timers)),
+		counters: make(map[statsdKey]float64),
+		gauges:   make(map[statsdKey]float64),
+		timings:  make(map[statsdKey][]float64),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, key := range config.Timers {
+		s.timers[key] = true
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *statsdEmitter) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// entryTags joins the level and TagFields tags of an entry
+func (s *statsdEmitter) entryTags(ent zapcore.Entry, context, fields []zapcore.Field) string {
+	tags := []string{"level:" + levelName(ent.Level)}
+	for _, key := range s.config.TagFields {
+		if v, ok := fieldValue(key, fields, context); ok {
+			tags = append(tags, key+":"+v)
+		}
+	}
+	return strings.Join(tags, ",")
+}
+
+func (s *statsdEmitter) process(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.DogStatsD {
+		s.counters[statsdKey{name: "log.entries", tags: s.entryTags(ent, context, fields)}]++
+	} else {
+		s.counters[statsdKey{name: "log.entries." + levelName(ent.Level)}]++
+	}
+	for _, f := range fields {
+		if kind, ok := f.Interface.(metricKind); ok && f.Type == zapcore.Float64Type {
+			value := math.Float64frombits(uint64(f.Integer))
+			switch kind {
+			case metricCounter:
+				s.counters[statsdKey{name: f.Key}] += value
+			case metricGauge:
+				s.gauges[statsdKey{name: f.Key}] = value
+			}
+			continue
+		}
+		if !s.timers[f.Key] {
+			continue
+		}
+		switch f.Type {
+		case zapcore.DurationType:
+			ms := float64(f.Integer) / float64(time.Millisecond)
+			s.timings[statsdKey{name: f.Key}] = append(s.timings[statsdKey{name: f.Key}], ms)
+		case zapcore.Int64Type, zapcore.Int32Type, zapcore.Uint64Type, zapcore.Uint32Type:
+			s.timings[statsdKey{name: f.Key}] = append(s.timings[statsdKey{name: f.Key}], float64(f.Integer))
+		case zapcore.Float64Type:
+			s.timings[statsdKey{name: f.Key}] = append(s.timings[statsdKey{name: f.Key}], math.Float64frombits(uint64(f.Integer)))
+		}
+	}
+	return ent, fields, true
+}
+
+// line formats one metric, name:value|type|#tags
+func (s *statsdEmitter) line(key statsdKey, value float64, typ string) string {
+	line := s.config.Prefix + metricName(key.name) + ":" + strconv.FormatFloat(value, 'f', -1, 64) + "|" + typ
+	if !s.config.DogStatsD {
+		return line
+	}
+	tags := key.tags
+	if len(s.config.Tags) > 0 {
+		if tags != "" {
+			tags += ","
+		}
+		tags += strings.Join(s.config.Tags, ",")
+	}
+	if tags != "" {
+		line += "|#" + tags
+	}
+	return line
+}
+
+// flush sends the aggregated metrics in packets fitting the usual MTU
+func (s *statsdEmitter) flush() {
+	s.mu.Lock()
+	var lines []string
+	for key, v := range s.counters {
+		lines = append(lines, s.line(key, v, "c"))
+	}
+	for key, v := range s.gauges {
+		lines = append(lines, s.line(key, v, "g"))
+	}
+	for key, values := range s.timings {
+		for _, v := range values {
+			lines = append(lines, s.line(key, v, "ms"))
+		}
+	}
+	s.counters = make(map[statsdKey]float64)
+	s.gauges = make(map[statsdKey]float64)
+	s.timings = make(map[statsdKey][]float64)
+	s.mu.Unlock()
+	sort.Strings(lines)
+
+	const maxPacket = 1432
+	var packet bytes.Buffer
+	send := func() {
+		if packet.Len() == 0 {
+			return
+		}
+		if _, err := s.conn.Write(packet.Bytes()); err != nil {
+			internalLog(zapcore.WarnLevel, "Failed send statsd metrics", String("address", s.config.Address), Err(err))
+		}
+		packet.Reset()
+	}
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxPacket {
+			send()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	send()
+}
+
+// Close sends the last metrics
+func (s *statsdEmitter) Close() error {
+	select {
+	case <-s.stop:
+		return nil
+	default:
+	}
+	close(s.stop)
+	<-s.done
+	s.flush()
+	return s.conn.Close()
+}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"os"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// StdioCaptureConfig redirects the file descriptors 1 and 2 of the process
+// into the logger, so the printf output of C libraries reaches the logfile.
+// The lines are logged with "source": "stdout_capture" and a "stream" field.
+//
+// os.Stdout and os.Stderr are replaced by copies of the original
+// descriptors, the console output of the logger and fmt.Print still go
+// there. C stdio buffers the pipe fully, call setvbuf or fflush for lines
+// to show up as written. With Stderr the panics and fatal errors of the Go
+// runtime go through the logger too and are lost when the process exits
+// before they are read.
+type StdioCaptureConfig struct {
+	// Stdout and Stderr select the redirected descriptors
+	Stdout bool
+	Stderr bool
+	// Level of the stdout lines, info by default
+	Level zapcore.Level
+	// StderrLevel of the stderr lines, warn when nil
+	StderrLevel *zapcore.Level
+}
+
+// fdCapture is a descriptor redirected into a pipe read by the logger
+type fdCapture struct {
+	fd int
+	// std is os.Stdout or os.Stderr, prev its value before the capture
+	std  **os.File
+	prev *os.File
+	// saved is the copy of the original descriptor std points to meanwhile
+	saved *os.File
+	w     *os.File
+}
+
+var stdioCaptures struct {
+	mu     sync.Mutex
+	stdout *fdCapture
+	stderr *fdCapture
+}
+
+// configureStdio starts and stops the captures, Configure calls it before
+// the writers pick os.Stdout and os.Stderr up
+func configureStdio(config *StdioCaptureConfig) {
+	stdioCaptures.mu.Lock()
+	defer stdioCaptures.mu.Unlock()
+
+	var stdout, stderr bool
+	level, stderrLevel := zapcore.InfoLevel, zapcore.WarnLevel
+	if config != nil {
+		stdout, stderr = config.Stdout, config.Stderr
+		level = config.Level
+		if config.StderrLevel != nil {
+			stderrLevel = *config.StderrLevel
+		}
+	}
+	toggleCapture(&stdioCaptures.stdout, stdout, 1, &os.Stdout, "stdout", level)
+	toggleCapture(&stdioCaptures.stderr, stderr, 2, &os.Stderr, "stderr", stderrLevel)
+}
+
+func toggleCapture(c **fdCapture, enabled bool, fd int, std **os.File, stream string, level zapcore.Level) {
+	switch {
+	case enabled && *c == nil:
+		capture, r, err := captureFd(fd, std)
+		if err != nil {
+			internalLog(zapcore.ErrorLevel, "Failed capture "+stream, Err(err))
+			return
+		}
+		*c = capture
+		go func() {
+			pipeLines(&Log{}, Parser{}, r, stream, level, String("source", "stdout_capture"))
+			r.Close()
+		}()
+	case !enabled && *c != nil:
+		if err := (*c).restore(); err != nil {
+			internalLog(zapcore.ErrorLevel, "Failed restore "+stream, Err(err))
+		}
+		*c = nil
+	}
+}
@@ -0,0 +1,49 @@
+//go:build linux
+
+package logger
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// captureFd points fd to a new pipe and std to a copy of the original
+// descriptor, the lines written to fd are read from the returned file
+func captureFd(fd int, std **os.File) (*fdCapture, *os.File, error) {
+	saved, err := syscall.Dup(fd)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		syscall.Close(saved)
+		return nil, nil, err
+	}
+	if err := syscall.Dup3(int(w.Fd()), fd, 0); err != nil {
+		syscall.Close(saved)
+		r.Close()
+		w.Close()
+		return nil, nil, err
+	}
+	c := &fdCapture{
+		fd:    fd,
+		std:   std,
+		prev:  *std,
+		saved: os.NewFile(uintptr(saved), (*std).Name()),
+		w:     w,
+	}
+	*std = c.saved
+	return c, r, nil
+}
+
+// restore points fd back to the original descriptor, the reader ends once
+// the child processes holding the pipe exited too
+func (c *fdCapture) restore() error {
+	err := syscall.Dup3(int(c.saved.Fd()), c.fd, 0)
+	*c.std = c.prev
+	c.w.Close()
+	// the writers of the replaced configuration still use the copy
+	time.AfterFunc(retireDelay, func() { c.saved.Close() })
+	return err
+}
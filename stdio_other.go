@@ -0,0 +1,16 @@
+//go:build !linux
+
+package logger
+
+import (
+	"errors"
+	"os"
+)
+
+func captureFd(fd int, std **os.File) (*fdCapture, *os.File, error) {
+	return nil, nil, errors.New("Stdio capture is only supported on linux")
+}
+
+func (c *fdCapture) restore() error {
+	return nil
+}
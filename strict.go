@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap/zapcore"
+)
+
+// droppedFields counts the fields removed by Config.AllowedFields per key
+var droppedFields = struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}{counts: make(map[string]uint64)}
+
+// DroppedFields returns how many fields of every key Config.AllowedFields
+// removed since the process started
+func DroppedFields() map[string]uint64 {
+	droppedFields.mu.Lock()
+	defer droppedFields.mu.Unlock()
+
+	counts := make(map[string]uint64, len(droppedFields.counts))
+	for k, n := range droppedFields.counts {
+		counts[k] = n
+	}
+	return counts
+}
+
+// newAllowlistProcess keeps the fields whose key is in allowed, counting
+// the others, into counter as well when not nil
+func newAllowlistProcess(allowed []string, counter prometheus.Counter) processFunc {
+	set := make(map[string]bool, len(allowed))
+	for _, key := range allowed {
+		set[key] = true
+	}
+	return func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+		out := make([]zapcore.Field, 0, len(context)+len(fields))
+		var dropped []string
+		for _, list := range [][]zapcore.Field{context, fields} {
+			for _, f := range list {
+				// skipped fields carry values for the processors, never written
+				if set[f.Key] || f.Type == zapcore.SkipType {
+					out = append(out, f)
+				} else {
+					dropped = append(dropped, f.Key)
+				}
+			}
+		}
+		if len(dropped) > 0 {
+			droppedFields.mu.Lock()
+			for _, key := range dropped {
+				droppedFields.counts[key]++
+			}
+			droppedFields.mu.Unlock()
+			if counter != nil {
+				counter.Add(float64(len(dropped)))
+			}
+		}
+		return ent, out, true
+	}
+}
+
+// allowlistCounter is the prometheus counter of the dropped fields
+func allowlistCounter(config Config) prometheus.Counter {
+	if config.MetricsRegisterer == nil {
+		return nil
+	}
+	m := newMetricsCollector(config.MetricsRegisterer, config.MetricsNamespace)
+	c, _ := m.register(prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: config.MetricsNamespace,
+		Name:      "log_fields_dropped_total",
+		Help:      "Fields removed from log entries by the field allowlist",
+	})).(prometheus.Counter)
+	return c
+}
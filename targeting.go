@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// DebugTarget logs the entries carrying a field with a given value from a
+// lower level than their logger, e.g. to capture the debug entries of one
+// customer without enabling debug for everybody:
+//
+//	logger.AddDebugTarget(logger.DebugTarget{
+//		Field:   "user_id",
+//		Value:   "42",
+//		Expires: time.Now().Add(time.Hour),
+//	})
+//
+// The fields of the entry and the fields added by With are matched.
+// While targets are set, entries below the logger level are built and
+// checked against them, which costs the work a disabled level saves.
+type DebugTarget struct {
+	// Field is the key of the matched field
+	Field string
+	// Value is compared to the field rendered as a string, numbers and
+	// other values as their JSON encoding
+	Value string
+	// Level is the level matching entries are logged from, default "debug"
+	Level string
+	// Expires removes the target after this time, never when zero
+	Expires time.Time
+}
+
+type debugTarget struct {
+	DebugTarget
+	level zapcore.Level
+}
+
+var debugTargets = struct {
+	mu      sync.RWMutex
+	targets []debugTarget
+	// count of targets set, checks skip the targets while it is 0
+	count atomic.Int32
+}{}
+
+// AddDebugTarget adds target, it replaces a target on the same field and value
+func AddDebugTarget(target DebugTarget) error {
+	if target.Field == "" {
+		return errors.New("Bad debug target")
+	}
+	if target.Level == "" {
+		target.Level = "debug"
+	}
+	level, err := parseLevel(target.Level)
+	if err != nil {
+		return err
+	}
+
+	debugTargets.mu.Lock()
+	defer debugTargets.mu.Unlock()
+	removeDebugTarget(target.Field, target.Value)
+	debugTargets.targets = append(debugTargets.targets, debugTarget{DebugTarget: target, level: level})
+	debugTargets.count.Store(int32(len(debugTargets.targets)))
+	return nil
+}
+
+// RemoveDebugTarget removes the target on field and value
+func RemoveDebugTarget(field, value string) {
+	debugTargets.mu.Lock()
+	defer debugTargets.mu.Unlock()
+	removeDebugTarget(field, value)
+	debugTargets.count.Store(int32(len(debugTargets.targets)))
+}
+
+// removeDebugTarget drops the target, debugTargets.mu must be held
+func removeDebugTarget(field, value string) {
+	kept := debugTargets.targets[:0]
+	for _, t := range debugTargets.targets {
+		if t.Field != field || t.Value != value {
+			kept = append(kept, t)
+		}
+	}
+	debugTargets.targets = kept
+}
+
+// DebugTargets returns the targets set, expired ones included until
+// their next match
+func DebugTargets() []DebugTarget {
+	debugTargets.mu.RLock()
+	defer debugTargets.mu.RUnlock()
+
+	targets := make([]DebugTarget, 0, len(debugTargets.targets))
+	for _, t := range debugTargets.targets {
+		targets = append(targets, t.DebugTarget)
+	}
+	return targets
+}
+
+// lowestTargetLevel is the most verbose level of the targets, ok is false
+// without targets
+func lowestTargetLevel() (zapcore.Level, bool) {
+	if debugTargets.count.Load() == 0 {
+		return 0, false
+	}
+
+	debugTargets.mu.RLock()
+	defer debugTargets.mu.RUnlock()
+	if len(debugTargets.targets) == 0 {
+		return 0, false
+	}
+	lowest := debugTargets.targets[0].level
+	for _, t := range debugTargets.targets[1:] {
+		if levelRank(t.level) < levelRank(lowest) {
+			lowest = t.level
+		}
+	}
+	return lowest, true
+}
+
+// targeted reports whether a target logs an entry at level with fields
+func targeted(level zapcore.Level, context, fields []zapcore.Field) bool {
+	now := time.Now()
+	expired := false
+	match := false
+
+	debugTargets.mu.RLock()
+	for _, t := range debugTargets.targets {
+		if !t.Expires.IsZero() && now.After(t.Expires) {
+			expired = true
+			continue
+		}
+		if levelAtLeast(level, t.level) && (hasFieldValue(fields, t.Field, t.Value) || hasFieldValue(context, t.Field, t.Value)) {
+			match = true
+			break
+		}
+	}
+	debugTargets.mu.RUnlock()
+
+	if expired {
+		pruneDebugTargets(now)
+	}
+	return match
+}
+
+func hasFieldValue(fields []zapcore.Field, key, value string) bool {
+	for i := range fields {
+		if fields[i].Key == key {
+			v := fieldMap(fields[i : i+1])[key]
+			return attributeString(v) == value
+		}
+	}
+	return false
+}
+
+func pruneDebugTargets(now time.Time) {
+	debugTargets.mu.Lock()
+	defer debugTargets.mu.Unlock()
+	kept := debugTargets.targets[:0]
+	for _, t := range debugTargets.targets {
+		if t.Expires.IsZero() || !now.After(t.Expires) {
+			kept = append(kept, t)
+		}
+	}
+	debugTargets.targets = kept
+	debugTargets.count.Store(int32(len(kept)))
+}
@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"container/list"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/natefinch/lumberjack"
+	"go.uber.org/zap/zapcore"
+)
+
+// TenantConfig routes the logfile entries carrying a tenant field to a
+// rolling file of their own, Directory/<tenant>/Filename, entries without
+// the field go to the regular logfile. Files are rolled like the logfile.
+type TenantConfig struct {
+	// Field holding the tenant, default "tenant_id"
+	Field string
+	// Directory holding the tenant directories, default <Config.Directory>/tenants
+	Directory string
+	// MaxOpen caps the open tenant files, the least recently used one is
+	// closed beyond it, default 128
+	MaxOpen int
+}
+
+type tenantFile struct {
+	tenant string
+	file   *lumberjack.Logger
+}
+
+// tenantWriter keeps the open tenant files in LRU order
+type tenantWriter struct {
+	config   Config
+	field    string
+	dir      string
+	max      int
+	fallback zapcore.WriteSyncer
+	mu       sync.Mutex
+	files    map[string]*list.Element
+	lru      *list.List
+}
+
+func newTenantWriter(config Config, fallback zapcore.WriteSyncer) (*tenantWriter, error) {
+	tc := *config.Tenant
+	if tc.Field == "" {
+		tc.Field = TenantIDField
+	}
+	if tc.Directory == "" {
+		dir := config.Directory
+		if dir == "" {
+			dir = "."
+		}
+		tc.Directory = filepath.Join(dir, "tenants")
+	}
+	if tc.MaxOpen <= 0 {
+		tc.MaxOpen = 128
+	}
+	if config.Filename == "" {
+		return nil, errors.New("Bad tenant log filename")
+	}
+	if err := os.MkdirAll(tc.Directory, 0755); err != nil {
+		return nil, err
+	}
+
+	return &tenantWriter{
+		config:   config,
+		field:    tc.Field,
+		dir:      tc.Directory,
+		max:      tc.MaxOpen,
+		fallback: fallback,
+		files:    make(map[string]*list.Element),
+		lru:      list.New(),
+	}, nil
+}
+
+// tenantDir keeps a tenant from escaping the tenant directory
+func tenantDir(tenant string) string {
+	dir := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, tenant)
+	if dir == "." || dir == ".." {
+		return "_"
+	}
+	return dir
+}
+
+func (w *tenantWriter) tenant(fields []zapcore.Field) string {
+	for i := len(fields) - 1; i >= 0; i-- {
+		f := fields[i]
+		if f.Key != w.field {
+			continue
+		}
+		if f.Type == zapcore.StringType {
+			return f.String
+		}
+		return attributeString(fieldMap(fields[i : i+1])[w.field])
+	}
+	return ""
+}
+
+// file returns the open file of tenant, w.mu must be held
+func (w *tenantWriter) file(tenant string) (*lumberjack.Logger, error) {
+	if e, ok := w.files[tenant]; ok {
+		w.lru.MoveToFront(e)
+		return e.Value.(*tenantFile).file, nil
+	}
+
+	dir := filepath.Join(w.dir, tenantDir(tenant))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f := &tenantFile{tenant: tenant, file: newLumberjack(w.config, filepath.Join(dir, w.config.Filename))}
+	w.files[tenant] = w.lru.PushFront(f)
+
+	for w.lru.Len() > w.max {
+		oldest := w.lru.Remove(w.lru.Back()).(*tenantFile)
+		delete(w.files, oldest.tenant)
+		oldest.file.Close()
+	}
+	return f.file, nil
+}
+
+func (w *tenantWriter) WriteEntry(ent zapcore.Entry, fields []zapcore.Field, encoded []byte) error {
+	tenant := w.tenant(fields)
+	if tenant == "" {
+		_, err := w.fallback.Write(encoded)
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	f, err := w.file(tenant)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(encoded)
+	return err
+}
+
+func (w *tenantWriter) Sync() error {
+	err := w.fallback.Sync()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for e := w.lru.Front(); e != nil; e = e.Next() {
+		if serr := (rollingFile{Logger: e.Value.(*tenantFile).file}).Sync(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+func (w *tenantWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	for e := w.lru.Front(); e != nil; e = e.Next() {
+		if cerr := e.Value.(*tenantFile).file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	w.files = make(map[string]*list.Element)
+	w.lru.Init()
+	return err
+}
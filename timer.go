@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Timer measures one step, see StartTimer
+type Timer struct {
+	log   *Log
+	name  string
+	start time.Time
+	slow  time.Duration
+}
+
+// StartTimer starts timing the step name, Stop logs the elapsed time:
+//
+//	t := logger.StartTimer(&log, "db_query").Slow(200 * time.Millisecond)
+//	rows, err := db.Query(q)
+//	t.Stop(logger.String("table", "users"))
+//
+// A nil log logs through the default logger.
+func StartTimer(log *Log, name string) *Timer {
+	if log == nil {
+		log = &Log{}
+	}
+	return &Timer{log: log, name: name, start: time.Now()}
+}
+
+// Slow makes Stop log only when the step took longer than threshold, at the
+// warn level instead of info
+func (t *Timer) Slow(threshold time.Duration) *Timer {
+	t.slow = threshold
+	return t
+}
+
+// Stop logs the step with its elapsed time and returns it
+func (t *Timer) Stop(fields ...zapcore.Field) time.Duration {
+	elapsed := time.Since(t.start)
+
+	fields = append(fields, String("step", t.name), Duration("elapsed", elapsed))
+	if t.slow <= 0 {
+		if ce := t.log.zap().Check(zapcore.InfoLevel, t.name); ce != nil {
+			t.log.write(ce, fields)
+		}
+	} else if elapsed > t.slow {
+		if ce := t.log.zap().Check(zapcore.WarnLevel, t.name); ce != nil {
+			t.log.write(ce, append(fields, Duration("slow_threshold", t.slow)))
+		}
+	}
+
+	return elapsed
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Transformer rewrites the fields of an entry, context added by With
+// included, it may change the slice in place
+type Transformer func(ent zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field)
+
+var (
+	transformersMu sync.RWMutex
+	transformers   []Transformer
+)
+
+// RegisterTransformer appends t to the pipeline run on every entry,
+// transformers run in registration order and apply from the next Configure:
+//
+//	logger.RegisterTransformer(logger.RenameField("userId", "user_id"))
+//	logger.RegisterTransformer(logger.RedactFields("password", "token"))
+func RegisterTransformer(t Transformer) {
+	transformersMu.Lock()
+	transformers = append(transformers, t)
+	transformersMu.Unlock()
+}
+
+func registeredTransformers() []Transformer {
+	transformersMu.RLock()
+	defer transformersMu.RUnlock()
+	return append([]Transformer(nil), transformers...)
+}
+
+func newTransformProcess(pipeline []Transformer) processFunc {
+	return func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+		all := make([]zapcore.Field, 0, len(context)+len(fields)+1)
+		all = append(append(all, context...), fields...)
+		for _, t := range pipeline {
+			ent, all = t(ent, all)
+		}
+		return ent, all, true
+	}
+}
+
+// RenameField renames the fields with key from to to
+func RenameField(from, to string) Transformer {
+	return func(ent zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field) {
+		for i := range fields {
+			if fields[i].Key == from {
+				fields[i].Key = to
+			}
+		}
+		return ent, fields
+	}
+}
+
+// DropFields removes the fields with the given keys
+func DropFields(keys ...string) Transformer {
+	return func(ent zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field) {
+		out := fields[:0]
+		for _, f := range fields {
+			if !contains(keys, f.Key) {
+				out = append(out, f)
+			}
+		}
+		return ent, out
+	}
+}
+
+// RedactFields replaces the value of the fields with the given keys by "[REDACTED]"
+func RedactFields(keys ...string) Transformer {
+	return func(ent zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field) {
+		for i := range fields {
+			if contains(keys, fields[i].Key) {
+				fields[i] = String(fields[i].Key, "[REDACTED]")
+			}
+		}
+		return ent, fields
+	}
+}
+
+// DeriveField adds the field returned by fn when ok is true
+func DeriveField(fn func(ent zapcore.Entry, fields []zapcore.Field) (field zapcore.Field, ok bool)) Transformer {
+	return func(ent zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field) {
+		if f, ok := fn(ent, fields); ok {
+			fields = append(fields, f)
+		}
+		return ent, fields
+	}
+}
@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TransportLogConfig configures WrapTransport
+type TransportLogConfig struct {
+	// Log receives the entries, default Named("http")
+	Log *Log
+	// Level of the requests, default debug, 5xx responses are logged at
+	// the warn level and transport errors at the error level
+	Level zapcore.Level
+	// SlowThreshold logs the requests taking longer at the warn level,
+	// disabled when 0
+	SlowThreshold time.Duration
+	// Headers logs the request and response headers
+	Headers bool
+	// RedactHeaders are logged as "***", default Authorization, Cookie,
+	// Set-Cookie, Proxy-Authorization and X-Api-Key
+	RedactHeaders []string
+	// MaxBody logs up to this many bytes of the request and response
+	// bodies, disabled when 0. The response body is read before
+	// RoundTrip returns, do not enable it for streamed responses.
+	MaxBody int
+}
+
+var defaultRedactHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization", "X-Api-Key"}
+
+type attemptsKey struct{}
+
+// WithAttempts returns a copy of ctx counting the round trips made with
+// it, so the requests of a retrying client sharing the context are logged
+// with their attempt number
+func WithAttempts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, attemptsKey{}, new(int32))
+}
+
+// WrapTransport returns a RoundTripper logging the outbound requests with
+// their method, URL without credentials, status and duration, nil rt
+// wraps http.DefaultTransport:
+//
+//	client := &http.Client{Transport: logger.WrapTransport(nil, logger.TransportLogConfig{MaxBody: 2048})}
+func WrapTransport(rt http.RoundTripper, config TransportLogConfig) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if config.Log == nil {
+		config.Log = Named("http")
+	}
+	if config.RedactHeaders == nil {
+		config.RedactHeaders = defaultRedactHeaders
+	}
+	return &logTransport{next: rt, config: config}
+}
+
+type logTransport struct {
+	next   http.RoundTripper
+	config TransportLogConfig
+}
+
+func (t *logTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fields := []zapcore.Field{String("method", req.Method), String("url", redactURL(req.URL))}
+	if n, ok := req.Context().Value(attemptsKey{}).(*int32); ok {
+		fields = append(fields, Int("attempt", int(atomic.AddInt32(n, 1))))
+	}
+	if t.config.Headers {
+		fields = append(fields, t.headers("request_headers", req.Header))
+	}
+	if t.config.MaxBody > 0 && req.Body != nil && req.Body != http.NoBody {
+		var body []byte
+		req = req.Clone(req.Context())
+		body, req.Body = peekBody(req.Body, t.config.MaxBody)
+		fields = append(fields, String("request_body", string(body)))
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start)
+	fields = append(fields, Duration("elapsed", elapsed))
+
+	level, msg := t.config.Level, "HTTP request"
+	if err != nil {
+		t.config.Log.Ctx(req.Context()).Error("HTTP request failed", append(fields, Err(err))...)
+		return resp, err
+	}
+	fields = append(fields, Int("status", resp.StatusCode))
+	switch {
+	case resp.StatusCode >= 500:
+		level = zapcore.WarnLevel
+	case t.config.SlowThreshold > 0 && elapsed >= t.config.SlowThreshold:
+		level, msg = zapcore.WarnLevel, "Slow HTTP request"
+	}
+	if t.config.Headers {
+		fields = append(fields, t.headers("response_headers", resp.Header))
+	}
+	if t.config.MaxBody > 0 && resp.Body != nil {
+		var body []byte
+		body, resp.Body = peekBody(resp.Body, t.config.MaxBody)
+		fields = append(fields, String("response_body", string(body)))
+	}
+	t.config.Log.Ctx(req.Context()).Log(level, msg, fields...)
+	return resp, nil
+}
+
+// headers renders h with the redacted values hidden
+func (t *logTransport) headers(key string, h http.Header) zapcore.Field {
+	m := make(map[string]string, len(h))
+	for name, values := range h {
+		value := strings.Join(values, ", ")
+		for _, r := range t.config.RedactHeaders {
+			if strings.EqualFold(name, r) {
+				value = "***"
+			}
+		}
+		m[name] = value
+	}
+	return zap.Any(key, m)
+}
+
+// redactURL drops the user password and keeps the rest
+func redactURL(u *url.URL) string {
+	if u.User == nil {
+		return u.String()
+	}
+	c := *u
+	c.User = url.User(u.User.Username())
+	return c.String()
+}
+
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// peekBody reads up to n bytes of body and returns them with a body
+// replaying them before the rest
+func peekBody(body io.ReadCloser, n int) ([]byte, io.ReadCloser) {
+	head, _ := io.ReadAll(io.LimitReader(body, int64(n)))
+	return head, peekedBody{Reader: io.MultiReader(bytes.NewReader(head), body), Closer: body}
+}
@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// Typed shortcuts for the most common entry shapes, a message with one to
+// three string pairs or one int pair:
+//
+//	log.InfoS2("Request done", "method", r.Method, "path", r.URL.Path)
+//
+// They log exactly like Info, Warn and Error with String or Int fields.
+
+// InfoS logs msg at the info level with one string field
+func (l *Log) InfoS(msg, k1, v1 string) {
+	if ce := l.zap().Check(zapcore.InfoLevel, msg); ce != nil {
+		l.writeS(ce, k1, v1)
+	}
+}
+
+// InfoS2 logs msg at the info level with two string fields
+func (l *Log) InfoS2(msg, k1, v1, k2, v2 string) {
+	if ce := l.zap().Check(zapcore.InfoLevel, msg); ce != nil {
+		l.writeS2(ce, k1, v1, k2, v2)
+	}
+}
+
+// InfoS3 logs msg at the info level with three string fields
+func (l *Log) InfoS3(msg, k1, v1, k2, v2, k3, v3 string) {
+	if ce := l.zap().Check(zapcore.InfoLevel, msg); ce != nil {
+		l.writeS3(ce, k1, v1, k2, v2, k3, v3)
+	}
+}
+
+// InfoI logs msg at the info level with one int field
+func (l *Log) InfoI(msg, k1 string, v1 int) {
+	if ce := l.zap().Check(zapcore.InfoLevel, msg); ce != nil {
+		l.writeI(ce, k1, v1)
+	}
+}
+
+// WarnS logs msg at the warn level with one string field
+func (l *Log) WarnS(msg, k1, v1 string) {
+	if ce := l.zap().Check(zapcore.WarnLevel, msg); ce != nil {
+		l.writeS(ce, k1, v1)
+	}
+}
+
+// WarnS2 logs msg at the warn level with two string fields
+func (l *Log) WarnS2(msg, k1, v1, k2, v2 string) {
+	if ce := l.zap().Check(zapcore.WarnLevel, msg); ce != nil {
+		l.writeS2(ce, k1, v1, k2, v2)
+	}
+}
+
+// WarnS3 logs msg at the warn level with three string fields
+func (l *Log) WarnS3(msg, k1, v1, k2, v2, k3, v3 string) {
+	if ce := l.zap().Check(zapcore.WarnLevel, msg); ce != nil {
+		l.writeS3(ce, k1, v1, k2, v2, k3, v3)
+	}
+}
+
+// WarnI logs msg at the warn level with one int field
+func (l *Log) WarnI(msg, k1 string, v1 int) {
+	if ce := l.zap().Check(zapcore.WarnLevel, msg); ce != nil {
+		l.writeI(ce, k1, v1)
+	}
+}
+
+// ErrorS logs msg at the error level with one string field
+func (l *Log) ErrorS(msg, k1, v1 string) {
+	if ce := l.zap().Check(zapcore.ErrorLevel, msg); ce != nil {
+		l.writeS(ce, k1, v1)
+	}
+}
+
+// ErrorS2 logs msg at the error level with two string fields
+func (l *Log) ErrorS2(msg, k1, v1, k2, v2 string) {
+	if ce := l.zap().Check(zapcore.ErrorLevel, msg); ce != nil {
+		l.writeS2(ce, k1, v1, k2, v2)
+	}
+}
+
+// ErrorS3 logs msg at the error level with three string fields
+func (l *Log) ErrorS3(msg, k1, v1, k2, v2, k3, v3 string) {
+	if ce := l.zap().Check(zapcore.ErrorLevel, msg); ce != nil {
+		l.writeS3(ce, k1, v1, k2, v2, k3, v3)
+	}
+}
+
+// ErrorI logs msg at the error level with one int field
+func (l *Log) ErrorI(msg, k1 string, v1 int) {
+	if ce := l.zap().Check(zapcore.ErrorLevel, msg); ce != nil {
+		l.writeI(ce, k1, v1)
+	}
+}
+
+// The write helpers append the fields straight into a pooled slice, no
+// variadic slice is built, BenchmarkInfoS shows 0 allocs/op like Info.
+
+func (l *Log) writeS(ce *zapcore.CheckedEntry, k1, v1 string) {
+	p := getFields(nil, 1)
+	*p = append(*p, String(k1, v1))
+	l.writePooled(ce, p)
+}
+
+func (l *Log) writeS2(ce *zapcore.CheckedEntry, k1, v1, k2, v2 string) {
+	p := getFields(nil, 2)
+	*p = append(*p, String(k1, v1), String(k2, v2))
+	l.writePooled(ce, p)
+}
+
+func (l *Log) writeS3(ce *zapcore.CheckedEntry, k1, v1, k2, v2, k3, v3 string) {
+	p := getFields(nil, 3)
+	*p = append(*p, String(k1, v1), String(k2, v2), String(k3, v3))
+	l.writePooled(ce, p)
+}
+
+func (l *Log) writeI(ce *zapcore.CheckedEntry, k1 string, v1 int) {
+	p := getFields(nil, 1)
+	*p = append(*p, Int(k1, v1))
+	l.writePooled(ce, p)
+}
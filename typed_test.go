@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"testing"
+)
+
+func BenchmarkInfoS(b *testing.B) {
+	discardLogger(b)
+	log := &Log{}
+	b.Run("InfoS", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			log.InfoS("Request done", "method", "GET")
+		}
+	})
+	b.Run("Info", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			log.Info("Request done", String("method", "GET"))
+		}
+	})
+	b.Run("InfoS3", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			log.InfoS3("Request done", "method", "GET", "path", "/", "host", "localhost")
+		}
+	})
+	b.Run("Info3", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			log.Info("Request done", String("method", "GET"), String("path", "/"), String("host", "localhost"))
+		}
+	})
+}
+
+func TestTypedAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	discardLogger(t)
+	log := &Log{}
+	calls := map[string]func(){
+		"InfoS":  func() { log.InfoS("Request done", "method", "GET") },
+		"InfoS2": func() { log.InfoS2("Request done", "method", "GET", "path", "/") },
+		"InfoS3": func() { log.InfoS3("Request done", "method", "GET", "path", "/", "host", "localhost") },
+		"InfoI":  func() { log.InfoI("Request done", "status", 200) },
+	}
+	for name, call := range calls {
+		if allocs := testing.AllocsPerRun(100, call); allocs != 0 {
+			t.Errorf("%s: %v allocs, want 0", name, allocs)
+		}
+	}
+}
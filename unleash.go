@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// UnleashLevels is a LevelProvider polling a feature of the Unleash
+// frontend API, gradual rollouts and strategies are evaluated by Unleash
+// for Context. While the feature is enabled the payload of its variant,
+// a level name or a RemoteSettings JSON document, is applied, Level when
+// it has none.
+type UnleashLevels struct {
+	// URL of the frontend API, e.g. https://unleash.example.com/api/frontend
+	URL string
+	// Token is a frontend API token
+	Token string
+	// Feature is the toggle name
+	Feature string
+	// Context is sent as the Unleash context, e.g. {"appName": "billing",
+	// "environment": "production", "properties[pod]": hostname}
+	Context map[string]string
+	// Level applied while the feature is enabled without payload, default "debug"
+	Level string
+	// Off is the level applied while the feature is disabled, default "info"
+	Off string
+	// Client defaults to a client with a 10s timeout
+	Client *http.Client
+}
+
+type unleashToggles struct {
+	Toggles []struct {
+		Name    string `json:"name"`
+		Enabled bool   `json:"enabled"`
+		Variant struct {
+			Enabled bool `json:"enabled"`
+			Payload *struct {
+				Value string `json:"value"`
+			} `json:"payload"`
+		} `json:"variant"`
+	} `json:"toggles"`
+}
+
+var unleashClient = &http.Client{Timeout: 10 * time.Second}
+
+func (u *UnleashLevels) Settings() (*RemoteSettings, error) {
+	query := url.Values{}
+	for k, v := range u.Context {
+		query.Set(k, v)
+	}
+	req, err := http.NewRequest(http.MethodGet, u.URL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", u.Token)
+	req.Header.Set("Accept", "application/json")
+
+	client := u.Client
+	if client == nil {
+		client = unleashClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("Unleash status " + resp.Status)
+	}
+	var toggles unleashToggles
+	if err := json.NewDecoder(resp.Body).Decode(&toggles); err != nil {
+		return nil, err
+	}
+
+	// the frontend API only lists the features enabled for the context
+	for _, t := range toggles.Toggles {
+		if t.Name != u.Feature || !t.Enabled {
+			continue
+		}
+		if t.Variant.Enabled && t.Variant.Payload != nil {
+			return flagSettings(t.Variant.Payload.Value)
+		}
+		return &RemoteSettings{Level: defaultString(u.Level, "debug")}, nil
+	}
+	return &RemoteSettings{Level: defaultString(u.Off, "info")}, nil
+}
+
+func (u *UnleashLevels) Changes() <-chan struct{} {
+	return nil
+}
+
+func defaultString(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Binary encodings, see Config.BinaryEncoding
+const (
+	BinaryBase64  = "base64"
+	BinaryHex     = "hex"
+	BinaryReplace = "replace"
+)
+
+// defaultBinaryMaxSize is the default of Config.BinaryMaxSize
+const defaultBinaryMaxSize = 4096
+
+// binaryValue is the object written by Binary
+type binaryValue struct {
+	encoding  string
+	data      string
+	size      int
+	truncated bool
+}
+
+func (b binaryValue) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("encoding", b.encoding)
+	enc.AddInt("size", b.size)
+	enc.AddString("data", b.data)
+	if b.truncated {
+		enc.AddBool("truncated", true)
+	}
+	return nil
+}
+
+// Binary logs b as {"encoding", "size", "data", "truncated"}, data is b
+// encoded with Config.BinaryEncoding, base64 by default, and cut at
+// Config.BinaryMaxSize bytes
+func Binary(name string, b []byte) zapcore.Field {
+	config := currentConfig()
+	max := config.BinaryMaxSize
+	if max <= 0 {
+		max = defaultBinaryMaxSize
+	}
+	v := binaryValue{encoding: config.BinaryEncoding, size: len(b)}
+	if v.encoding == "" {
+		v.encoding = BinaryBase64
+	}
+	if len(b) > max {
+		b, v.truncated = b[:max], true
+	}
+	v.data = encodeBinary(v.encoding, b)
+	return zapcore.Field{Key: name, Type: zapcore.ObjectMarshalerType, Interface: v}
+}
+
+func encodeBinary(encoding string, b []byte) string {
+	switch encoding {
+	case BinaryHex:
+		return hex.EncodeToString(b)
+	case BinaryReplace:
+		return strings.ToValidUTF8(string(b), "�")
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// newUTF8Process rewrites the string fields holding invalid UTF-8 with
+// encoding, the message gets the invalid bytes replaced
+func newUTF8Process(encoding string) processFunc {
+	return func(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+		if !utf8.ValidString(ent.Message) {
+			ent.Message = strings.ToValidUTF8(ent.Message, "�")
+		}
+		for i := range fields {
+			switch f := fields[i]; f.Type {
+			case zapcore.StringType:
+				if !utf8.ValidString(f.String) {
+					fields[i] = String(f.Key, encodeBinary(encoding, []byte(f.String)))
+				}
+			case zapcore.ByteStringType:
+				if b, ok := f.Interface.([]byte); ok && !utf8.Valid(b) {
+					fields[i] = String(f.Key, encodeBinary(encoding, b))
+				}
+			}
+		}
+		return ent, fields, true
+	}
+}
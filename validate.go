@@ -0,0 +1,282 @@
+package logger
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Validate checks config without opening files or connections, the
+// returned error lists every problem found
+func (c Config) Validate() error {
+	var problems []string
+	bad := func(problem string) {
+		problems = append(problems, problem)
+	}
+
+	if !validEncoding(c.encoding()) {
+		bad("encoding " + c.Encoding)
+	}
+	if c.FileLoggingEnabled && c.Filename == "" {
+		bad("file logging without filename")
+	}
+	if c.MaxSize < 0 || c.MaxBackups < 0 || c.MaxAge < 0 {
+		bad("negative file size, backups or age")
+	}
+	if c.RecentEntries < 0 {
+		bad("negative recent entries")
+	}
+	if c.FileShards < 0 {
+		bad("negative file shards")
+	}
+	if c.Mmap != nil && c.Mmap.ChunkSize < 0 {
+		bad("negative mmap chunk size")
+	}
+	if c.Fsync != nil && (c.Fsync.Entries < 0 || c.Fsync.Interval < 0) {
+		bad("negative fsync entries or interval")
+	}
+	if c.DiskGuard != nil && (c.DiskGuard.MinFree < 0 || c.DiskGuard.Interval < 0) {
+		bad("negative disk guard free space or interval")
+	}
+	if c.Retention != nil && (c.Retention.Days < 0 || c.Retention.Rotations < 0 || c.Retention.CheckInterval < 0) {
+		bad("negative retention days, rotations or interval")
+	}
+	if c.Archive != nil && (c.Archive.Store == nil || c.Archive.Retries < 0) {
+		bad("archive without store or with negative retries")
+	}
+	if c.FileAttrs != nil && (c.FileAttrs.FileMode&^os.ModePerm != 0 || c.FileAttrs.DirMode&^os.ModePerm != 0) {
+		bad("file or directory mode beyond permission bits")
+	}
+	if c.Tenant != nil && !c.FileLoggingEnabled {
+		bad("tenant files without file logging")
+	}
+	switch c.Engine {
+	case "", EngineRing:
+	default:
+		bad("engine " + c.Engine)
+	}
+	switch c.Multiline {
+	case "", MultilineEscape, MultilineLines:
+	default:
+		bad("multiline policy " + c.Multiline)
+	}
+	switch c.BinaryEncoding {
+	case "", BinaryBase64, BinaryHex, BinaryReplace:
+	default:
+		bad("binary encoding " + c.BinaryEncoding)
+	}
+	switch c.CorrelationIDFormat {
+	case "", CorrelationUUIDv7, CorrelationULID, CorrelationKSUID:
+	default:
+		bad("correlation id format " + c.CorrelationIDFormat)
+	}
+	if c.Async != nil && (c.Async.QueueSize < 0 || c.Async.DropReportInterval < 0) {
+		bad("negative async queue size or report interval")
+	}
+	switch c.KeyCase {
+	case "", KeyCaseSnake:
+	default:
+		bad("key case " + c.KeyCase)
+	}
+	switch c.DuplicateKeys {
+	case "", DuplicateLastWins, DuplicateError, DuplicateSuffix:
+	default:
+		bad("duplicate keys policy " + c.DuplicateKeys)
+	}
+	if c.Sampling != nil && (c.Sampling.First < 0 || c.Sampling.Thereafter < 0 || c.Sampling.RateLimit < 0 || c.Sampling.ReportInterval < 0) {
+		bad("negative sampling limits")
+	}
+	if a := c.AdaptiveSampling; a != nil && (a.QueueHigh < 0 || a.QueueHigh > 1 || a.MinRate < 0 || a.MinRate > 1 || a.LatencyHigh < 0 || a.Interval < 0) {
+		bad("adaptive sampling thresholds out of range")
+	}
+	if c.WideEvents != nil && (c.WideEvents.APIKey == "" || c.WideEvents.Dataset == "") {
+		bad("wide events without api key or dataset")
+	}
+	if c.StatsD != nil && c.StatsD.Address == "" {
+		bad("statsd without address")
+	}
+	if c.SLO != nil && c.SLO.Tracker == nil {
+		bad("SLO without tracker")
+	}
+	if c.SourceLink != nil && c.SourceLink.Template == "" {
+		bad("source link without template")
+	}
+	if c.DualWrite != nil && c.DualWrite.Logrus == nil {
+		bad("dual write without logrus logger")
+	}
+	if c.IPTruncation != nil && (c.IPTruncation.IPv4Bits < 0 || c.IPTruncation.IPv4Bits > 32 || c.IPTruncation.IPv6Bits < 0 || c.IPTruncation.IPv6Bits > 128) {
+		bad("ip truncation prefix out of range")
+	}
+	if c.CrashLoop != nil && c.CrashLoop.StateFile == "" {
+		bad("crash loop without state file")
+	}
+	for _, sink := range c.sinks() {
+		validateSink(c, sink, bad)
+	}
+	for i, f := range c.Filters {
+		if f.Message == nil && len(f.HasFields) == 0 && f.Package == "" && f.MaxLevel == nil && f.Modify == nil {
+			bad("filter " + strconv.Itoa(i) + " drops every entry")
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("Bad config: " + strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+func validateSink(c Config, sink SinkConfig, bad func(string)) {
+	name := sink.name()
+	if sink.Encoding != "" && !validEncoding(sink.Encoding) {
+		bad(name + " encoding " + sink.Encoding)
+	}
+	switch sink.Type {
+	case SinkConsole:
+	case SinkFile:
+		if sink.File == nil || sink.File.Filename == "" {
+			bad(name + " without filename")
+		}
+	case SinkSocket:
+		if sink.Socket == nil {
+			bad(name + " without socket options")
+		} else {
+			validateSocket(name, *sink.Socket, bad)
+		}
+	case SinkNATS:
+		if sink.NATS == nil || sink.NATS.Subject == "" {
+			bad(name + " without subject")
+		}
+	case SinkClickHouse:
+		if sink.ClickHouse == nil || sink.ClickHouse.DB == nil || sink.ClickHouse.Table == "" {
+			bad(name + " without db or table")
+		}
+	case SinkSQLite:
+		if sink.SQLite == nil || sink.SQLite.Path == "" || sink.SQLite.MaxRows < 0 || sink.SQLite.MaxAge < 0 {
+			bad(name + " without path or with negative retention")
+		}
+	case SinkMQTT:
+		if sink.MQTT == nil || sink.MQTT.Broker == "" || sink.MQTT.Topic == "" || sink.MQTT.QoS > 2 {
+			bad(name + " without broker or topic or with qos above 2")
+		}
+	case SinkEventLog:
+		if sink.EventLog == nil || sink.EventLog.Source == "" {
+			bad(name + " without source")
+		}
+	case SinkSIEM:
+		if sink.CEF == nil || sink.CEF.Socket == nil {
+			bad(name + " without socket options")
+		} else {
+			validateSocket(name, *sink.CEF.Socket, bad)
+		}
+	case SinkJournald:
+	case SinkIncident:
+		if sink.Incident == nil || sink.Incident.Key == "" ||
+			sink.Incident.Provider != IncidentPagerDuty && sink.Incident.Provider != IncidentOpsgenie {
+			bad(name + " without key or with unknown provider")
+		}
+	case SinkAzureMonitor:
+		if sink.AzureMonitor == nil || sink.AzureMonitor.WorkspaceID == "" || sink.AzureMonitor.SharedKey == "" || sink.AzureMonitor.LogType == "" {
+			bad(name + " without workspace, key or log type")
+		}
+	case SinkEmail:
+		if sink.Email == nil || sink.Email.Addr == "" || sink.Email.From == "" || len(sink.Email.To) == 0 {
+			bad(name + " without server, sender or recipient")
+		}
+	default:
+		bad("sink type " + sink.Type)
+	}
+}
+
+func validateSocket(name string, config SocketConfig, bad func(string)) {
+	switch config.Network {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "unix", "unixgram":
+	default:
+		bad(name + " network " + config.Network)
+	}
+	if config.Address == "" {
+		bad(name + " without address")
+	}
+	switch config.Framing {
+	case "", FramingNewline, FramingLength:
+	default:
+		bad(name + " framing " + config.Framing)
+	}
+}
+
+// Preview validates config and renders a sample entry the way every
+// configured output would write it, one "output: entry" line each, binary
+// encodings are hex dumped. Nothing is opened or sent.
+func Preview(config Config) (string, error) {
+	if err := config.Validate(); err != nil {
+		return "", err
+	}
+
+	ent := zapcore.Entry{
+		Level:      zapcore.InfoLevel,
+		Time:       time.Now(),
+		LoggerName: "preview",
+		Message:    "Preview entry",
+	}
+	if config.Caller {
+		ent.Caller = zapcore.NewEntryCaller(0, "github.com/acme/svc/main.go", 42, true)
+	}
+	fields := []zapcore.Field{
+		String("request_id", "4bf92f35"),
+		Int("status", 200),
+		Duration("latency", 12*time.Millisecond),
+		Bool("cached", false),
+		Err(errors.New("sample error")),
+	}
+	if config.CallerFunc {
+		fields = append(fields, String(FuncField, "main.handle"))
+	}
+
+	var out strings.Builder
+	render := func(output, encoding string) error {
+		buf, err := newEncoder(config, encoding).EncodeEntry(ent, fields)
+		if err != nil {
+			return err
+		}
+		defer buf.Free()
+
+		out.WriteString(output + ": ")
+		if data := buf.Bytes(); utf8.Valid(data) {
+			out.Write(data)
+		} else {
+			out.WriteString("\n" + hex.Dump(data))
+		}
+		if !strings.HasSuffix(out.String(), "\n") {
+			out.WriteByte('\n')
+		}
+		return nil
+	}
+
+	var outputs [][2]string
+	if !config.DisableStdout {
+		outputs = append(outputs, [2]string{"stdout", config.consoleEncoding()})
+	}
+	if config.FileLoggingEnabled {
+		dir := config.Directory
+		if dir == "" {
+			dir = "."
+		}
+		outputs = append(outputs, [2]string{"file " + filepath.Join(dir, config.Filename), config.encoding()})
+	}
+	for _, sink := range config.sinks() {
+		outputs = append(outputs, [2]string{sink.name() + " " + sink.target(), sink.encoding(config)})
+	}
+
+	for _, o := range outputs {
+		if err := render(o[0], o[1]); err != nil {
+			return "", err
+		}
+	}
+	return out.String(), nil
+}
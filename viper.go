@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/knadh/koanf/v2"
+	"github.com/spf13/viper"
+)
+
+// DecodeHook converts level names such as "warn" or "notice" and duration
+// strings such as "10s" while decoding a Config with mapstructure
+func DecodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		levelDecodeHook,
+		mapstructure.StringToTimeDurationHookFunc(),
+	)
+}
+
+func levelDecodeHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String || to != levelType {
+		return data, nil
+	}
+	return parseLevel(data.(string))
+}
+
+// FromViper reads the Config under key, the whole of v when key is empty.
+// Keys match the Config fields case insensitively, e.g.
+//
+//	log:
+//	  loglevel: warn
+//	  directory: /var/log/app
+//	  runtimestatsinterval: 30s
+//
+// The result is validated, a decoded Config still needs Configure.
+func FromViper(v *viper.Viper, key string) (Config, error) {
+	var config Config
+	if key != "" {
+		if v = v.Sub(key); v == nil {
+			return config, errors.New("Missing logger config")
+		}
+	}
+	if err := v.Unmarshal(&config, viper.DecodeHook(DecodeHook())); err != nil {
+		return config, err
+	}
+	return config, config.Validate()
+}
+
+// FromKoanf is FromViper for koanf
+func FromKoanf(k *koanf.Koanf, key string) (Config, error) {
+	var config Config
+	if key != "" && !k.Exists(key) {
+		return config, errors.New("Missing logger config")
+	}
+	err := k.UnmarshalWithConf(key, &config, koanf.UnmarshalConf{
+		DecoderConfig: &mapstructure.DecoderConfig{
+			DecodeHook:       DecodeHook(),
+			Result:           &config,
+			WeaklyTypedInput: true,
+		},
+	})
+	if err != nil {
+		return config, err
+	}
+	return config, config.Validate()
+}
@@ -0,0 +1,203 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// HoneycombConfig configures the wide events, one event per request
+// carrying every field added during its lifetime, sent in batches to the
+// Honeycomb events API or a compatible one such as OpenObserve:
+//
+//	ctx := logger.StartWideEvent(r.Context())
+//	defer logger.FinishWideEvent(ctx)
+//	...
+//	logger.AddWideFields(ctx, logger.String("user_id", id), logger.Int("cart_items", n))
+//
+// The entries logged with Log.Ctx during the request add their fields too,
+// and the error and above ones set "error" to their message.
+type HoneycombConfig struct {
+	// APIKey is sent as X-Honeycomb-Team
+	APIKey string
+	// Dataset receiving the events
+	Dataset string
+	// Endpoint default https://api.honeycomb.io
+	Endpoint string
+	// BatchSize is the number of events per request, default 100
+	BatchSize int
+	// FlushInterval is the max time events wait before a request, default 1s
+	FlushInterval time.Duration
+	// Client defaults to a client with a 10s timeout
+	Client *http.Client
+}
+
+// wideEvent accumulates the fields of one request
+type wideEvent struct {
+	mu     sync.Mutex
+	start  time.Time
+	fields map[string]interface{}
+	done   bool
+}
+
+type wideEventKey struct{}
+
+// honeycombEvent is an event of the batch API
+type honeycombEvent struct {
+	Time string                 `json:"time"`
+	Data map[string]interface{} `json:"data"`
+}
+
+type honeycombSender struct {
+	config HoneycombConfig
+	url    string
+	client *http.Client
+	batch  *batcher[honeycombEvent]
+}
+
+var wideSender atomic.Pointer[honeycombSender]
+
+var honeycombClient = &http.Client{Timeout: 10 * time.Second}
+
+func newHoneycombSender(config HoneycombConfig) (*honeycombSender, error) {
+	if config.APIKey == "" || config.Dataset == "" {
+		return nil, errors.New("Bad honeycomb api key or dataset")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://api.honeycomb.io"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	s := &honeycombSender{
+		config: config,
+		url:    config.Endpoint + "/1/batch/" + url.PathEscape(config.Dataset),
+		client: config.Client,
+	}
+	if s.client == nil {
+		s.client = honeycombClient
+	}
+	s.batch = newBatcher("honeycomb", config.BatchSize, config.FlushInterval, s.send)
+	return s, nil
+}
+
+func (s *honeycombSender) send(events []honeycombEvent) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Honeycomb-Team", s.config.APIKey)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.New("Honeycomb status " + strconv.Itoa(resp.StatusCode))
+	}
+	return nil
+}
+
+func (s *honeycombSender) Close() error {
+	return s.batch.Close()
+}
+
+// StartWideEvent returns a copy of ctx carrying a new wide event
+func StartWideEvent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, wideEventKey{}, &wideEvent{start: time.Now(), fields: make(map[string]interface{})})
+}
+
+func wideEventOf(ctx context.Context) *wideEvent {
+	e, _ := ctx.Value(wideEventKey{}).(*wideEvent)
+	return e
+}
+
+// add merges fields, later values win, nothing is added once finished
+func (e *wideEvent) add(fields []zapcore.Field) {
+	values := fieldMap(fields)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.done {
+		return
+	}
+	for k, v := range values {
+		e.fields[k] = v
+	}
+}
+
+// AddWideFields adds fields to the wide event of ctx, nothing happens
+// without one
+func AddWideFields(ctx context.Context, fields ...zapcore.Field) {
+	if e := wideEventOf(ctx); e != nil {
+		e.add(fields)
+	}
+}
+
+// FinishWideEvent sends the wide event of ctx with its duration_ms, once,
+// the event is dropped when Config.WideEvents is not set
+func FinishWideEvent(ctx context.Context) {
+	e := wideEventOf(ctx)
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	if e.done {
+		e.mu.Unlock()
+		return
+	}
+	e.done = true
+	// the batcher marshals data later, it must not share the event map
+	data := make(map[string]interface{}, len(e.fields)+2)
+	for k, v := range e.fields {
+		data[k] = v
+	}
+	e.mu.Unlock()
+
+	s := wideSender.Load()
+	if s == nil {
+		return
+	}
+	data["duration_ms"] = float64(time.Since(e.start)) / float64(time.Millisecond)
+	if id := CorrelationID(ctx); id != "" {
+		data[CorrelationField] = id
+	}
+	if err := s.batch.Add(honeycombEvent{Time: e.start.UTC().Format(time.RFC3339Nano), Data: data}); err != nil {
+		internalLog(zapcore.WarnLevel, "Failed send wide events", String("dataset", s.config.Dataset), Err(err))
+	}
+}
+
+// wideEventProcess adds the fields of the entries logged with Log.Ctx to
+// the wide event of their context
+func wideEventProcess(ent zapcore.Entry, context, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field, bool) {
+	ctx := entryContext(context, fields)
+	if ctx == nil {
+		return ent, fields, true
+	}
+	e := wideEventOf(ctx)
+	if e == nil {
+		return ent, fields, true
+	}
+	e.add(context)
+	e.add(fields)
+	if levelAtLeast(ent.Level, zapcore.ErrorLevel) {
+		e.add([]zapcore.Field{String("error", ent.Message)})
+	}
+	return ent, fields, true
+}
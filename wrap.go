@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"errors"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// wrappedError annotates an error with a message and the fields logged with it
+type wrappedError struct {
+	msg    string
+	err    error
+	fields []zapcore.Field
+}
+
+func (e *wrappedError) Error() string {
+	return e.msg + ": " + e.err.Error()
+}
+
+func (e *wrappedError) Unwrap() error {
+	return e.err
+}
+
+// Wrap logs err at the error level with msg and fields and returns err
+// annotated with them. Only the origin logs: wrapping an error that already
+// went through Wrap annotates it without logging it a second time, so every
+// layer can wrap without double logging:
+//
+//	if err := db.Query(q); err != nil {
+//		return logger.Wrap(err, "Query failed", logger.String("query", q))
+//	}
+//
+// Wrap returns nil when err is nil.
+func Wrap(err error, msg string, fields ...zapcore.Field) error {
+	if err == nil {
+		return nil
+	}
+
+	var prev *wrappedError
+	if !errors.As(err, &prev) {
+		currentZap().Error(msg, append(fields[:len(fields):len(fields)], Err(err))...)
+	}
+
+	return &wrappedError{msg: msg, err: err, fields: fields}
+}
+
+// ErrorFields returns the fields attached by Wrap along the chain of err,
+// outermost first
+func ErrorFields(err error) []zapcore.Field {
+	var fields []zapcore.Field
+	for err != nil {
+		if w, ok := err.(*wrappedError); ok {
+			fields = append(fields, w.fields...)
+		}
+		err = errors.Unwrap(err)
+	}
+	return fields
+}